@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// commandSource runs an executable on every refresh and reads the tasks it
+// prints to stdout as a JSON array:
+//
+//	[
+//	  {
+//	    "id": "PROJ-12",
+//	    "name": "Fix the login page",
+//	    "status": "open",
+//	    "project": "Website",
+//	    "tags": ["bug"],
+//	    "scheduled": "2025-01-08T09:00:00Z",
+//	    "deadline": "2025-01-10T17:00:00Z"
+//	  }
+//	]
+//
+// Only id and name are required. status is "open" (the default), "completed",
+// or "canceled", and dates are in RFC 3339 format. A task that disappears from
+// the output is treated like a task deleted from Things. The command exits
+// with a non-zero status to report an error.
+type commandSource struct {
+	// command is run with sh -c.
+	command string
+	runner  commandRunner
+}
+
+func newCommandSource(opts sourceOptions) (TaskSource, error) {
+	if opts.command == "" {
+		return nil, errors.New("the command source needs --command")
+	}
+	return commandSource{command: opts.command, runner: opts.getRunner()}, nil
+}
+
+func (s commandSource) ID() string {
+	return "command:" + s.command
+}
+
+func (s commandSource) Capabilities() sourceCapabilities {
+	return sourceCapabilities{Polling: true}
+}
+
+func (s commandSource) Fetch() ([]task, error) {
+	output, err := s.runner.Run("sh", "-c", s.command)
+	if err != nil {
+		return nil, err
+	}
+	return decodeTasks(output)
+}
+
+// jsonTask is a task in the JSON format that commandSource reads.
+type jsonTask struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Status    string     `json:"status"`
+	Project   string     `json:"project,omitempty"`
+	Tags      []string   `json:"tags,omitempty"`
+	Scheduled *time.Time `json:"scheduled,omitempty"`
+	Deadline  *time.Time `json:"deadline,omitempty"`
+}
+
+// decodeTasks decodes a JSON array of tasks, validating their fields.
+func decodeTasks(data []byte) ([]task, error) {
+	var decoded []jsonTask
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+	tasks := make([]task, 0, len(decoded))
+	seen := make(map[string]bool)
+	for i, jt := range decoded {
+		if jt.ID == "" {
+			return nil, fmt.Errorf("task %d has no id", i)
+		}
+		if seen[jt.ID] {
+			return nil, fmt.Errorf("task %d has the same id as another task: %s", i, jt.ID)
+		}
+		seen[jt.ID] = true
+		if jt.Name == "" {
+			return nil, fmt.Errorf("task %s has no name", jt.ID)
+		}
+		switch jt.Status {
+		case "":
+			jt.Status = StatusOpen
+		case StatusOpen, StatusCompleted, StatusCanceled:
+		default:
+			return nil, fmt.Errorf("task %s has an unknown status: %s", jt.ID, jt.Status)
+		}
+		tasks = append(tasks, task{
+			ID:        jt.ID,
+			Name:      jt.Name,
+			Status:    jt.Status,
+			Project:   jt.Project,
+			Tags:      jt.Tags,
+			Scheduled: jt.Scheduled,
+			Deadline:  jt.Deadline,
+		})
+	}
+	return tasks, nil
+}
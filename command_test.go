@@ -0,0 +1,77 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDecodeTasks(t *testing.T) {
+	data := `[
+		{"id": "1", "name": "Fix the login page", "project": "Website", "tags": ["bug"], "deadline": "2025-01-10T17:00:00Z"},
+		{"id": "2", "name": "Update docs", "status": "completed"},
+		{"id": "3", "name": "Old idea", "status": "canceled", "parentId": "1"}
+	]`
+	tasks, err := decodeTasks([]byte(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tasks) != 3 {
+		t.Fatalf("expected 3 tasks, got %d", len(tasks))
+	}
+	if tasks[0].Status != StatusOpen || tasks[0].Project != "Website" || len(tasks[0].Tags) != 1 {
+		t.Errorf("unexpected first task: %+v", tasks[0])
+	}
+	if tasks[0].Deadline == nil || !tasks[0].Deadline.Equal(time.Date(2025, 1, 10, 17, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected deadline: %v", tasks[0].Deadline)
+	}
+	if tasks[1].Status != StatusCompleted || tasks[2].Status != StatusCanceled {
+		t.Errorf("unexpected statuses: %s, %s", tasks[1].Status, tasks[2].Status)
+	}
+	if tasks[2].ParentID != nil {
+		t.Error("tasks from a command should never have a parent")
+	}
+}
+
+func TestDecodeTasksRejectsInvalidTasks(t *testing.T) {
+	tests := map[string]string{
+		"invalid JSON":   `{"id": "1"}`,
+		"missing id":     `[{"name": "No ID"}]`,
+		"missing name":   `[{"id": "1"}]`,
+		"duplicate id":   `[{"id": "1", "name": "A"}, {"id": "1", "name": "B"}]`,
+		"unknown status": `[{"id": "1", "name": "A", "status": "started"}]`,
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := decodeTasks([]byte(data)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestCommandSourceRunsCommand(t *testing.T) {
+	source, err := newCommandSource(sourceOptions{command: `echo '[{"id": "1", "name": "From a command"}]'`})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tasks, err := source.Fetch()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].Name != "From a command" {
+		t.Errorf("unexpected tasks: %+v", tasks)
+	}
+}
+
+func TestCommandSourceReturnsErrorWhenCommandFails(t *testing.T) {
+	source, _ := newCommandSource(sourceOptions{command: "exit 1"})
+	if _, err := source.Fetch(); err == nil {
+		t.Error("expected an error when the command fails")
+	}
+}
+
+func TestNewCommandSourceRequiresCommand(t *testing.T) {
+	if _, err := newCommandSource(sourceOptions{}); err == nil {
+		t.Error("expected an error without a command")
+	}
+}
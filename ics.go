@@ -0,0 +1,282 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// icsSource reads VTODO components from iCalendar files. A path can also be a
+// directory of .ics files, like the calendar collections that vdirsyncer
+// writes.
+type icsSource struct {
+	paths []string
+}
+
+func newICSSource(opts sourceOptions) (TaskSource, error) {
+	if len(opts.icsPaths) == 0 {
+		return nil, errors.New("the ics source needs at least one --ics-path")
+	}
+	var paths []string
+	for _, path := range opts.icsPaths {
+		path, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return icsSource{paths: paths}, nil
+}
+
+func (s icsSource) ID() string {
+	return "ics:" + strings.Join(s.paths, ",")
+}
+
+func (s icsSource) Capabilities() sourceCapabilities {
+	return sourceCapabilities{Watch: s.paths}
+}
+
+func (s icsSource) Fetch() ([]task, error) {
+	files, err := s.files()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var tasks []task
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, parseICSTodos(string(data), now)...)
+	}
+	return tasks, nil
+}
+
+// files returns the .ics files at the source's paths, expanding directories.
+func (s icsSource) files() ([]string, error) {
+	var files []string
+	for _, path := range s.paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(path, "*.ics"))
+		if err != nil {
+			return nil, err
+		}
+		// Glob sorts the matches, so tasks keep their order between fetches.
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+// icsProperty is a content line of an iCalendar file, e.g.
+// DUE;TZID=Europe/Berlin:20250110T170000.
+type icsProperty struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// parseICSTodos returns the VTODO components of an iCalendar file as tasks.
+//
+// Like Things does with its Today list, completed and canceled todos are only
+// kept if they were closed today, since calendar apps keep them around forever.
+func parseICSTodos(data string, now time.Time) []task {
+	startOfToday := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	var tasks []task
+	var todo map[string]icsProperty
+	// depth counts the components nested in the current VTODO, like VALARM,
+	// whose properties belong to them instead of the todo.
+	depth := 0
+	for _, line := range unfoldICSLines(data) {
+		prop := parseICSProperty(line)
+		switch {
+		case prop.name == "BEGIN" && strings.EqualFold(prop.value, "VTODO") && todo == nil:
+			todo = make(map[string]icsProperty)
+		case todo == nil:
+			continue
+		case prop.name == "BEGIN":
+			depth++
+		case prop.name == "END" && depth > 0:
+			depth--
+		case prop.name == "END" && strings.EqualFold(prop.value, "VTODO"):
+			if t, ok := icsTodoToTask(todo, startOfToday); ok {
+				tasks = append(tasks, t)
+			}
+			todo = nil
+		case depth == 0:
+			if _, exists := todo[prop.name]; !exists {
+				todo[prop.name] = prop
+			} else if prop.name == "CATEGORIES" {
+				// CATEGORIES can be repeated.
+				categories := todo[prop.name]
+				categories.value += "," + prop.value
+				todo[prop.name] = categories
+			}
+		}
+	}
+	return tasks
+}
+
+func icsTodoToTask(todo map[string]icsProperty, startOfToday time.Time) (task, bool) {
+	uid := todo["UID"].value
+	if uid == "" {
+		return task{}, false
+	}
+
+	status := StatusOpen
+	switch strings.ToUpper(todo["STATUS"].value) {
+	case "COMPLETED":
+		status = StatusCompleted
+	case "CANCELLED":
+		status = StatusCanceled
+	case "":
+		if _, ok := todo["COMPLETED"]; ok {
+			status = StatusCompleted
+		}
+	}
+	if status != StatusOpen {
+		closed, ok := todo["COMPLETED"]
+		if !ok {
+			closed = todo["LAST-MODIFIED"]
+		}
+		if at, err := parseICSTime(closed); err == nil && at.Before(startOfToday) {
+			return task{}, false
+		}
+	}
+
+	// Overridden instances of a recurring todo share its UID, and are told
+	// apart by the instance they replace.
+	id := uid
+	if recurrence := todo["RECURRENCE-ID"].value; recurrence != "" {
+		id += "#" + recurrence
+	}
+
+	t := task{
+		ID:     id,
+		Name:   unescapeICSText(todo["SUMMARY"].value),
+		Status: status,
+	}
+	if categories := todo["CATEGORIES"].value; categories != "" {
+		for _, category := range splitICSList(categories) {
+			if category != "" {
+				t.Tags = append(t.Tags, category)
+			}
+		}
+	}
+	if due, ok := todo["DUE"]; ok {
+		if at, err := parseICSTime(due); err == nil {
+			t.Deadline = &at
+		}
+	}
+	return t, true
+}
+
+// unfoldICSLines splits an iCalendar file into content lines, joining lines
+// that were folded with a leading space or tab.
+func unfoldICSLines(data string) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func parseICSProperty(line string) icsProperty {
+	prop := icsProperty{params: make(map[string]string)}
+	// The value starts after the first colon that isn't in a quoted parameter.
+	inQuotes := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			inQuotes = !inQuotes
+		} else if r == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon == -1 {
+		prop.name = strings.ToUpper(line)
+		return prop
+	}
+	prop.value = line[colon+1:]
+	parts := strings.Split(line[:colon], ";")
+	prop.name = strings.ToUpper(parts[0])
+	for _, param := range parts[1:] {
+		key, value, _ := strings.Cut(param, "=")
+		prop.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+	return prop
+}
+
+// parseICSTime parses DATE and DATE-TIME values, in UTC if they end with Z,
+// in the time zone of their TZID parameter, or in local time.
+func parseICSTime(prop icsProperty) (time.Time, error) {
+	location := time.Local
+	if strings.HasSuffix(prop.value, "Z") {
+		// The Z of the layout is a literal, so the zone comes from here.
+		location = time.UTC
+	} else if tzid := prop.params["TZID"]; tzid != "" {
+		if loc, err := time.LoadLocation(tzid); err == nil {
+			location = loc
+		}
+	}
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405", "20060102"} {
+		if strings.HasSuffix(layout, "Z") != strings.HasSuffix(prop.value, "Z") {
+			continue
+		}
+		if at, err := time.ParseInLocation(layout, prop.value, location); err == nil {
+			return at, nil
+		}
+	}
+	return time.Time{}, errors.New("invalid iCalendar date: " + prop.value)
+}
+
+// splitICSList splits a comma-separated value, ignoring escaped commas.
+func splitICSList(value string) []string {
+	var items []string
+	var current strings.Builder
+	escaped := false
+	for _, r := range value {
+		switch {
+		case escaped:
+			current.WriteRune('\\')
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == ',':
+			items = append(items, unescapeICSText(current.String()))
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	items = append(items, unescapeICSText(current.String()))
+	return items
+}
+
+func unescapeICSText(value string) string {
+	return strings.NewReplacer(
+		`\n`, "\n",
+		`\N`, "\n",
+		`\,`, ",",
+		`\;`, ";",
+		`\\`, `\`,
+	).Replace(value)
+}
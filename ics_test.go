@@ -0,0 +1,152 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testICS = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VTODO\r\n" +
+	"UID:report@example.com\r\n" +
+	"SUMMARY:Write the quarterly report\\, then send\r\n" +
+	"  it\r\n" +
+	"STATUS:NEEDS-ACTION\r\n" +
+	"CATEGORIES:Work,Writing\r\n" +
+	"DUE;TZID=UTC:20250110T170000\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"SUMMARY:Not the todo's summary\r\n" +
+	"END:VALARM\r\n" +
+	"END:VTODO\r\n" +
+	"BEGIN:VTODO\r\n" +
+	"UID:milk@example.com\r\n" +
+	"SUMMARY:Buy milk\r\n" +
+	"STATUS:COMPLETED\r\n" +
+	"COMPLETED:20250105T080000Z\r\n" +
+	"END:VTODO\r\n" +
+	"BEGIN:VTODO\r\n" +
+	"UID:old@example.com\r\n" +
+	"SUMMARY:Done long ago\r\n" +
+	"COMPLETED:20241201T080000Z\r\n" +
+	"END:VTODO\r\n" +
+	"BEGIN:VTODO\r\n" +
+	"UID:flights@example.com\r\n" +
+	"SUMMARY:Book flights\r\n" +
+	"STATUS:CANCELLED\r\n" +
+	"DUE;VALUE=DATE:20250111\r\n" +
+	"END:VTODO\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:meeting@example.com\r\n" +
+	"SUMMARY:Not a todo\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParseICSTodos(t *testing.T) {
+	now := time.Date(2025, 1, 5, 12, 0, 0, 0, time.UTC)
+	tasks := parseICSTodos(testICS, now)
+
+	if len(tasks) != 3 {
+		t.Fatalf("expected 3 tasks, got %d: %+v", len(tasks), tasks)
+	}
+
+	report := tasks[0]
+	if report.ID != "report@example.com" || report.Name != "Write the quarterly report, then send it" {
+		t.Errorf("unexpected task: %+v", report)
+	}
+	if report.Status != StatusOpen {
+		t.Errorf("expected open, got %s", report.Status)
+	}
+	if strings.Join(report.Tags, ",") != "Work,Writing" {
+		t.Errorf("unexpected tags: %v", report.Tags)
+	}
+	if report.Deadline == nil || !report.Deadline.Equal(time.Date(2025, 1, 10, 17, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected deadline: %v", report.Deadline)
+	}
+
+	if tasks[1].ID != "milk@example.com" || tasks[1].Status != StatusCompleted {
+		t.Errorf("expected completed milk task, got %+v", tasks[1])
+	}
+	if tasks[2].ID != "flights@example.com" || tasks[2].Status != StatusCanceled {
+		t.Errorf("expected canceled flights task, got %+v", tasks[2])
+	}
+	if tasks[2].Deadline == nil || tasks[2].Deadline.Day() != 11 {
+		t.Errorf("unexpected date deadline: %v", tasks[2].Deadline)
+	}
+}
+
+func TestParseICSTimeReadsZAsUTC(t *testing.T) {
+	original := time.Local
+	defer func() { time.Local = original }()
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone data isn't available")
+	}
+	time.Local = newYork
+
+	at, err := parseICSTime(parseICSProperty("DUE:20250110T170000Z"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !at.Equal(time.Date(2025, 1, 10, 17, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected 17:00 UTC, got %v", at.UTC())
+	}
+}
+
+func TestParseICSTodosKeepsRecurrenceOverridesApart(t *testing.T) {
+	data := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VTODO\r\n" +
+		"UID:plants@example.com\r\n" +
+		"SUMMARY:Water the plants\r\n" +
+		"RRULE:FREQ=WEEKLY\r\n" +
+		"DUE;VALUE=DATE:20250106\r\n" +
+		"END:VTODO\r\n" +
+		"BEGIN:VTODO\r\n" +
+		"UID:plants@example.com\r\n" +
+		"RECURRENCE-ID;VALUE=DATE:20250113\r\n" +
+		"SUMMARY:Water the plants and repot the fern\r\n" +
+		"DUE;VALUE=DATE:20250114\r\n" +
+		"END:VTODO\r\n" +
+		"END:VCALENDAR\r\n"
+
+	tasks := parseICSTodos(data, time.Date(2025, 1, 5, 12, 0, 0, 0, time.UTC))
+	if len(tasks) != 2 {
+		t.Fatalf("expected 2 tasks, got %d: %+v", len(tasks), tasks)
+	}
+	if tasks[0].ID != "plants@example.com" {
+		t.Errorf("expected the recurring todo to keep its UID, got %s", tasks[0].ID)
+	}
+	if tasks[1].ID != "plants@example.com#20250113" {
+		t.Errorf("expected the override to have its own ID, got %s", tasks[1].ID)
+	}
+}
+
+func TestICSSourceReadsDirectories(t *testing.T) {
+	dir := t.TempDir()
+	for name, uid := range map[string]string{"a.ics": "a", "b.ics": "b", "c.txt": "c"} {
+		content := "BEGIN:VCALENDAR\nBEGIN:VTODO\nUID:" + uid + "\nSUMMARY:Task " + uid + "\nEND:VTODO\nEND:VCALENDAR\n"
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("failed to write test file: %v", err)
+		}
+	}
+
+	source, err := newICSSource(sourceOptions{icsPaths: []string{dir}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tasks, err := source.Fetch()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tasks) != 2 || tasks[0].ID != "a" || tasks[1].ID != "b" {
+		t.Errorf("expected tasks a and b, got %+v", tasks)
+	}
+}
+
+func TestNewICSSourceRequiresPaths(t *testing.T) {
+	if _, err := newICSSource(sourceOptions{}); err == nil {
+		t.Error("expected an error without paths")
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// stdin is where the json source reads tasks from when --from is "-".
+var stdin io.Reader = os.Stdin
+
+// jsonSource reads tasks in the format of commandSource from a file, or once
+// from stdin.
+type jsonSource struct {
+	// path is the file with the tasks, or "-" for stdin.
+	path string
+	// tasks are the tasks read from stdin, which can only be read once.
+	tasks []task
+	// digest identifies what was read from stdin, so unrelated pipelines
+	// don't share their priorities.
+	digest string
+}
+
+func newJSONSource(opts sourceOptions) (TaskSource, error) {
+	switch opts.from {
+	case "":
+		return nil, errors.New("the json source needs --from <path>, or --from - for stdin")
+	case "-":
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return nil, err
+		}
+		tasks, err := decodeTasks(data)
+		if err != nil {
+			return nil, fmt.Errorf("reading tasks from stdin: %w", err)
+		}
+		sum := sha1.Sum(data)
+		return jsonSource{path: "-", tasks: tasks, digest: hex.EncodeToString(sum[:])[:12]}, nil
+	}
+	path, err := filepath.Abs(opts.from)
+	if err != nil {
+		return nil, err
+	}
+	return jsonSource{path: path}, nil
+}
+
+func (s jsonSource) ID() string {
+	if s.path == "-" {
+		return "json:-:" + s.digest
+	}
+	return "json:" + s.path
+}
+
+func (s jsonSource) Capabilities() sourceCapabilities {
+	if s.path == "-" {
+		// Stdin can't change after it was read, so there's nothing to poll.
+		return sourceCapabilities{}
+	}
+	return sourceCapabilities{Watch: []string{s.path}}
+}
+
+func (s jsonSource) Fetch() ([]task, error) {
+	if s.path == "-" {
+		return append([]task(nil), s.tasks...), nil
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+	return decodeTasks(data)
+}
+
+// emitRanking writes the tasks to w from the highest priority to the lowest,
+// as ranked by ranker, followed by the snoozed tasks and then the completed
+// and canceled tasks. Tasks that still need to be compared are in the best
+// order known so far. The
+// format is "json", for the format that jsonSource reads, or "text", for one
+// task name per line.
+func emitRanking(w io.Writer, format string, ranker Ranker, tasks []task) error {
+	ordered, pending := ranker.Ordered(tasks)
+	for _, group := range pending {
+		ordered = append(ordered, group...)
+	}
+	for _, t := range tasks {
+		if t.Status == StatusOpen && t.Snoozed {
+			ordered = append(ordered, t)
+		}
+	}
+	for _, t := range tasks {
+		if t.Status == StatusCompleted || t.Status == StatusCanceled {
+			ordered = append(ordered, t)
+		}
+	}
+
+	switch format {
+	case "json":
+		encoded := make([]jsonTask, 0, len(ordered))
+		for _, t := range ordered {
+			encoded = append(encoded, newJSONTask(t))
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(encoded)
+	case "text":
+		var b strings.Builder
+		for _, t := range ordered {
+			if t.Status == StatusOpen {
+				b.WriteString(t.Name + "\n")
+			}
+		}
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+	return fmt.Errorf("unknown format %q (available: json, text)", format)
+}
+
+func newJSONTask(t task) jsonTask {
+	return jsonTask{
+		ID:        t.ID,
+		Name:      t.Name,
+		Status:    t.Status,
+		Project:   t.Project,
+		Tags:      t.Tags,
+		Scheduled: t.Scheduled,
+		Deadline:  t.Deadline,
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestJSONSourceReadsStdinOnce(t *testing.T) {
+	original := stdin
+	defer func() { stdin = original }()
+	stdin = strings.NewReader(`[{"id": "1", "name": "From stdin"}]`)
+
+	source, err := newJSONSource(sourceOptions{from: "-"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if source.Capabilities().Polling {
+		t.Error("stdin should not be polled")
+	}
+	for range 2 {
+		tasks, err := source.Fetch()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(tasks) != 1 || tasks[0].Name != "From stdin" {
+			t.Errorf("unexpected tasks: %+v", tasks)
+		}
+	}
+}
+
+func TestJSONSourceRejectsInvalidStdin(t *testing.T) {
+	original := stdin
+	defer func() { stdin = original }()
+	stdin = strings.NewReader(`not json`)
+
+	if _, err := newJSONSource(sourceOptions{from: "-"}); err == nil {
+		t.Error("expected an error for invalid JSON on stdin")
+	}
+}
+
+func TestJSONSourceIDDependsOnStdin(t *testing.T) {
+	original := stdin
+	defer func() { stdin = original }()
+
+	var ids []string
+	for _, input := range []string{
+		`[{"id": "1", "name": "Groceries"}]`,
+		`[{"id": "1", "name": "Groceries"}]`,
+		`[{"id": "1", "name": "Release notes"}]`,
+	} {
+		stdin = strings.NewReader(input)
+		source, err := newJSONSource(sourceOptions{from: "-"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ids = append(ids, source.ID())
+	}
+	if ids[0] != ids[1] {
+		t.Errorf("expected the same input to keep its ID, got %s and %s", ids[0], ids[1])
+	}
+	if ids[0] == ids[2] {
+		t.Errorf("expected different inputs to have different IDs, got %s", ids[0])
+	}
+}
+
+func TestJSONSourceReadsFile(t *testing.T) {
+	path := writeTestFile(t, "tasks.json", `[{"id": "1", "name": "From a file", "status": "completed"}]`)
+	source, err := newJSONSource(sourceOptions{from: path})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if watch := source.Capabilities().Watch; len(watch) != 1 || watch[0] != path {
+		t.Errorf("expected the file to be watched, got %v", watch)
+	}
+	tasks, err := source.Fetch()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].Status != StatusCompleted {
+		t.Errorf("unexpected tasks: %+v", tasks)
+	}
+}
+
+func TestNewJSONSourceRequiresFrom(t *testing.T) {
+	if _, err := newJSONSource(sourceOptions{}); err == nil {
+		t.Error("expected an error without --from")
+	}
+}
+
+func TestEmitRankingJSONRoundTrips(t *testing.T) {
+	tasks := CreateTestTasks(3)
+	tasks[0].ParentID = &tasks[1].ID
+	tasks[2].Status = StatusCompleted
+
+	var out bytes.Buffer
+	if err := emitRanking(&out, "json", newTreeRanker(), tasks); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	emitted, err := decodeTasks(out.Bytes())
+	if err != nil {
+		t.Fatalf("emitted JSON should be readable with --from: %v", err)
+	}
+	var ids []string
+	for _, t := range emitted {
+		ids = append(ids, t.ID)
+	}
+	if strings.Join(ids, ",") != "b,a,c" {
+		t.Errorf("expected order b,a,c, got %v", ids)
+	}
+}
+
+func TestEmitRankingKeepsSnoozedTasks(t *testing.T) {
+	tasks := CreateTestTasks(3)
+	tasks[0].Snoozed = true
+	tasks[1].Status = StatusCompleted
+
+	var out bytes.Buffer
+	if err := emitRanking(&out, "json", newTreeRanker(), tasks); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	emitted, err := decodeTasks(out.Bytes())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var ids []string
+	for _, t := range emitted {
+		ids = append(ids, t.ID)
+	}
+	if strings.Join(ids, ",") != "c,a,b" {
+		t.Errorf("expected the snoozed task between the open and closed ones, got %v", ids)
+	}
+}
+
+func TestEmitRankingText(t *testing.T) {
+	tasks := CreateTestTasks(3)
+	tasks[0].ParentID = &tasks[1].ID
+	tasks[2].Status = StatusCanceled
+
+	var out bytes.Buffer
+	if err := emitRanking(&out, "text", newTreeRanker(), tasks); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.String() != "Task B\nTask A\n" {
+		t.Errorf("unexpected output: %q", out.String())
+	}
+}
+
+// reversedRanker ranks like treeRanker, but orders the tasks the other way
+// around.
+type reversedRanker struct {
+	treeRanker
+}
+
+func (r reversedRanker) Ordered(tasks []task) ([]task, [][]task) {
+	ranked, pending := r.treeRanker.Ordered(tasks)
+	for _, group := range pending {
+		ranked = append(ranked, group...)
+	}
+	slices.Reverse(ranked)
+	return ranked, nil
+}
+
+func TestEmitRankingUsesTheRanker(t *testing.T) {
+	tasks := CreateTestTasks(3)
+	tasks[0].ParentID = &tasks[1].ID
+	tasks[2].ParentID = &tasks[0].ID
+
+	var out bytes.Buffer
+	if err := emitRanking(&out, "text", reversedRanker{}, tasks); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.String() != "Task C\nTask A\nTask B\n" {
+		t.Errorf("expected the order of the ranker, got %q", out.String())
+	}
+}
+
+func TestEmitRankingRejectsUnknownFormat(t *testing.T) {
+	if err := emitRanking(&bytes.Buffer{}, "xml", newTreeRanker(), nil); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
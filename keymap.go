@@ -8,6 +8,18 @@ type KeyMap struct {
 	Undo        key.Binding
 	Scroll      key.Binding
 	Reset       key.Binding
+	Details     key.Binding
+	Retry       key.Binding
+	Complete    key.Binding
+	Cancel      key.Binding
+	AddTask     key.Binding
+	RenameLeft  key.Binding
+	RenameRight key.Binding
+	SnoozeLeft  key.Binding
+	SnoozeRight key.Binding
+	NextTask    key.Binding
+	Confirm     key.Binding
+	Dismiss     key.Binding
 	Help        key.Binding
 	Quit        key.Binding
 }
@@ -33,6 +45,54 @@ var DefaultKeyMap = KeyMap{
 		key.WithKeys("ctrl+r"),
 		key.WithHelp("ctrl+r", "Reset priorities"),
 	),
+	Details: key.NewBinding(
+		key.WithKeys("d"),
+		key.WithHelp("d", "Toggle task details"),
+	),
+	Retry: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "Sync now"),
+	),
+	Complete: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "Complete a task"),
+	),
+	Cancel: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "Cancel a task"),
+	),
+	AddTask: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "Add task"),
+	),
+	RenameLeft: key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "Rename left task"),
+	),
+	RenameRight: key.NewBinding(
+		key.WithKeys("E"),
+		key.WithHelp("E", "Rename right task"),
+	),
+	SnoozeLeft: key.NewBinding(
+		key.WithKeys("z"),
+		key.WithHelp("z", "Snooze left task"),
+	),
+	SnoozeRight: key.NewBinding(
+		key.WithKeys("Z"),
+		key.WithHelp("Z", "Snooze right task"),
+	),
+	NextTask: key.NewBinding(
+		key.WithKeys("tab"),
+		key.WithHelp("tab", "Next task"),
+	),
+	Confirm: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "Confirm"),
+	),
+	Dismiss: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "Cancel"),
+	),
 	Help: key.NewBinding(
 		key.WithKeys("?"),
 		key.WithHelp("?", "All keybindings"),
@@ -50,7 +110,9 @@ func (k KeyMap) ShortHelp() []key.Binding {
 func (k KeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
 		{k.ChooseLeft, k.ChooseRight, k.Scroll},
-		{k.Reset, k.Undo},
+		{k.Reset, k.Undo, k.Details, k.Retry},
+		{k.AddTask, k.RenameLeft, k.RenameRight, k.SnoozeLeft, k.SnoozeRight},
+		{k.Complete, k.Cancel},
 		{k.Help, k.Quit},
 	}
 }
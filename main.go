@@ -2,7 +2,13 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -10,18 +16,195 @@ import (
 
 var refreshInterval time.Duration
 
-func parseFlags() time.Duration {
+// stringsFlag is a flag that can be given multiple times.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ", ")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// options holds the values of the command-line flags.
+type options struct {
+	refreshInterval time.Duration
+	source          string
+	sourceOptions   sourceOptions
+	// emit is the format the ranking is printed to stdout in on exit, or empty
+	// to print nothing.
+	emit string
+	// commandTimeout is how long commands of sources may run.
+	commandTimeout time.Duration
+	// ranking is the name of the ranker that decides which tasks are compared.
+	ranking string
+}
+
+func parseFlags() options {
 	refreshIntervalSeconds := flag.Int("refresh-interval", 3, "Refresh interval in seconds")
+	source := flag.String(
+		"source",
+		"things",
+		fmt.Sprintf("Where to get tasks from (%s), or a comma-separated list of them", strings.Join(sourceNames(), ", ")),
+	)
+	thingsList := flag.String("list", "", "Things list to prioritize: "+strings.Join(thingsLists, ", ")+" (default: Today)")
+	thingsProject := flag.String("project", "", "Things project to prioritize instead of a list")
+	thingsArea := flag.String("area", "", "Things area to prioritize instead of a list")
+	thingsTag := flag.String("tag", "", "Only prioritize Things to-dos with this tag")
+	thingsWritePriorities := flag.Bool(
+		"things-write-priorities",
+		false,
+		"Tag the ranked Things to-dos sift:1, sift:2, ... once every task is prioritized",
+	)
+	thingsFocusTag := flag.String("things-focus-tag", "", "Tag the top Things to-dos of the ranking with this tag, e.g. Focus")
+	thingsFocusCount := flag.Int("things-focus-count", 3, "How many of the top Things to-dos get the --things-focus-tag")
+	todoFile := flag.String("todo-file", "", "Path of the todo.txt file for the todotxt source (default: $TODO_FILE)")
+	todoWritePriorities := flag.Bool(
+		"todo-write-priorities",
+		false,
+		"Write the ranking to the todo.txt file as (A), (B), ... priorities once every task is prioritized",
+	)
+	var markdownFiles stringsFlag
+	flag.Var(&markdownFiles, "markdown-file", "Path of a Markdown file with checklists for the markdown source (repeatable)")
+	taskwarriorFile := flag.String("taskwarrior-file", "", "Path of a file with the output of task export for the taskwarrior source")
+	taskwarriorCommand := flag.String(
+		"taskwarrior-command",
+		"task export",
+		"Command that exports the tasks for the taskwarrior source, when there's no --taskwarrior-file",
+	)
+	var icsPaths stringsFlag
+	flag.Var(&icsPaths, "ics-path", "Path of an .ics file or a directory of them for the ics source (repeatable)")
+	var orgFiles stringsFlag
+	flag.Var(&orgFiles, "org-file", "Path of an Org-mode file for the org source (repeatable)")
+	orgTodoKeywords := flag.String("org-todo-keywords", "TODO,NEXT", "Comma-separated Org keywords of open tasks")
+	orgDoneKeywords := flag.String("org-done-keywords", "DONE", "Comma-separated Org keywords of completed tasks")
+	orgCanceledKeywords := flag.String("org-canceled-keywords", "CANCELLED,CANCELED", "Comma-separated Org keywords of canceled tasks")
+	command := flag.String("command", "", "Command that prints the tasks as JSON for the command source")
+	from := flag.String("from", "", "Read tasks as JSON from a file, or from stdin with -, for the json source")
+	ranking := flag.String("ranking", defaultRanking, "How tasks are ranked: "+strings.Join(rankerNames(), ", "))
+	emit := flag.String("emit", "", "Print the ranking to stdout on exit as json or text, and draw the interface on stderr")
+	commandTimeout := defaultCommandTimeout
+	flag.Func(
+		"command-timeout",
+		fmt.Sprintf("Seconds that commands like osascript may run before they are killed (default %d)", int(defaultCommandTimeout/time.Second)),
+		func(value string) error {
+			seconds, err := strconv.Atoi(value)
+			if err != nil {
+				return err
+			}
+			if seconds < 1 {
+				// Every fetch would time out right away.
+				return errors.New("must be at least 1")
+			}
+			commandTimeout = time.Duration(seconds) * time.Second
+			return nil
+		},
+	)
 	flag.Parse()
-	return time.Duration(*refreshIntervalSeconds) * time.Second
+
+	// --from on its own selects the json source.
+	sourceSet := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "source" {
+			sourceSet = true
+		}
+	})
+	if *from != "" && !sourceSet {
+		*source = "json"
+	}
+
+	return options{
+		refreshInterval: time.Duration(*refreshIntervalSeconds) * time.Second,
+		source:          *source,
+		sourceOptions: sourceOptions{
+			thingsList:            *thingsList,
+			thingsProject:         *thingsProject,
+			thingsArea:            *thingsArea,
+			thingsTag:             *thingsTag,
+			thingsWritePriorities: *thingsWritePriorities,
+			thingsFocusTag:        *thingsFocusTag,
+			thingsFocusCount:      *thingsFocusCount,
+			todoFile:              *todoFile,
+			todoWritePriorities:   *todoWritePriorities,
+			markdownFiles:         markdownFiles,
+			taskwarriorFile:       *taskwarriorFile,
+			taskwarriorCommand:    *taskwarriorCommand,
+			icsPaths:              icsPaths,
+			orgFiles:              orgFiles,
+			orgTodoKeywords:       *orgTodoKeywords,
+			orgDoneKeywords:       *orgDoneKeywords,
+			orgCanceledKeywords:   *orgCanceledKeywords,
+			command:               *command,
+			from:                  *from,
+		},
+		emit:           *emit,
+		commandTimeout: commandTimeout,
+		ranking:        *ranking,
+	}
 }
 
 func main() {
-	refreshInterval = parseFlags()
+	opts := parseFlags()
+	refreshInterval = opts.refreshInterval
+	if opts.emit != "" && opts.emit != "json" && opts.emit != "text" {
+		fmt.Fprintf(os.Stderr, "sift: unknown --emit format %q (available: json, text)\n", opts.emit)
+		os.Exit(2)
+	}
+
+	// Canceling ctx kills the commands that are still running when we quit.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opts.sourceOptions.runner = newExecRunner(ctx, opts.commandTimeout)
+
+	source, err := newSource(opts.source, opts.sourceOptions)
+	if err != nil {
+		// The logger discards everything in prod builds, so tell the user
+		// directly.
+		fmt.Fprintln(os.Stderr, "sift:", err)
+		os.Exit(2)
+	}
+	stateFile = stateFileName(source.ID())
+	ranker, err := newRanker(opts.ranking)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "sift:", err)
+		os.Exit(2)
+	}
 
 	Logger.Info("Starting sift-terminal")
-	p := tea.NewProgram(initialModel(), tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
+	m := initialModel()
+	m.source = source
+	m.ranker = ranker
+	if paths := source.Capabilities().Watch; len(paths) > 0 {
+		watcher, err := newSourceWatcher(paths)
+		if err != nil {
+			// Polling still picks up the changes.
+			Logger.Errorf("Can't watch the files of the source, polling instead: %v", err)
+		} else {
+			defer watcher.Close()
+			m.watcher = watcher
+		}
+	}
+	programOptions := []tea.ProgramOption{tea.WithAltScreen()}
+	if opts.sourceOptions.from == "-" {
+		// Stdin holds the tasks, so read the keyboard from the terminal.
+		programOptions = append(programOptions, tea.WithInputTTY())
+	}
+	if opts.emit != "" {
+		// Keep stdout free for the ranking.
+		programOptions = append(programOptions, tea.WithOutput(os.Stderr))
+	}
+	p := tea.NewProgram(m, programOptions...)
+	finalModel, err := p.Run()
+	cancel()
+	if err != nil {
 		Logger.Fatal(err)
 	}
+	if opts.emit != "" {
+		if err := emitRanking(os.Stdout, opts.emit, finalModel.(model).ranker, finalModel.(model).allTasks); err != nil {
+			fmt.Fprintln(os.Stderr, "sift:", err)
+			os.Exit(1)
+		}
+	}
 }
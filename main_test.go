@@ -1,8 +1,10 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -14,7 +16,7 @@ func TestParseFlagsDefaultValue(t *testing.T) {
 	// Set up args with no flags (should use default)
 	os.Args = []string{"sift"}
 
-	interval := parseFlags()
+	interval := parseFlags().refreshInterval
 	expected := 3 * time.Second
 
 	if interval != expected {
@@ -29,7 +31,7 @@ func TestParseFlagsCustomValue(t *testing.T) {
 	// Set up args with custom refresh interval
 	os.Args = []string{"sift", "--refresh-interval", "10"}
 
-	interval := parseFlags()
+	interval := parseFlags().refreshInterval
 	expected := 10 * time.Second
 
 	if interval != expected {
@@ -44,7 +46,7 @@ func TestParseFlagsShortForm(t *testing.T) {
 	// Set up args with short form flag
 	os.Args = []string{"sift", "--refresh-interval=5"}
 
-	interval := parseFlags()
+	interval := parseFlags().refreshInterval
 	expected := 5 * time.Second
 
 	if interval != expected {
@@ -59,7 +61,7 @@ func TestParseFlagsZeroValue(t *testing.T) {
 	// Set up args with zero refresh interval
 	os.Args = []string{"sift", "--refresh-interval", "0"}
 
-	interval := parseFlags()
+	interval := parseFlags().refreshInterval
 	expected := 0 * time.Second
 
 	if interval != expected {
@@ -74,10 +76,99 @@ func TestParseFlagsLargeValue(t *testing.T) {
 	// Set up args with large refresh interval
 	os.Args = []string{"sift", "--refresh-interval", "3600"}
 
-	interval := parseFlags()
+	interval := parseFlags().refreshInterval
 	expected := 3600 * time.Second
 
 	if interval != expected {
 		t.Errorf("Expected large interval %v, got %v", expected, interval)
 	}
 }
+
+func TestParseFlagsDefaultSource(t *testing.T) {
+	// Reset flag.CommandLine to avoid conflicts with other tests
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+
+	os.Args = []string{"sift"}
+
+	source := parseFlags().source
+	if source != "things" {
+		t.Errorf("Expected default source things, got %s", source)
+	}
+}
+
+func TestParseFlagsCustomSource(t *testing.T) {
+	// Reset flag.CommandLine to avoid conflicts with other tests
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+
+	os.Args = []string{"sift", "--source", "todotxt"}
+
+	source := parseFlags().source
+	if source != "todotxt" {
+		t.Errorf("Expected source todotxt, got %s", source)
+	}
+}
+
+func TestParseFlagsRepeatedMarkdownFiles(t *testing.T) {
+	// Reset flag.CommandLine to avoid conflicts with other tests
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+
+	os.Args = []string{"sift", "--markdown-file", "a.md", "--markdown-file", "b.md"}
+
+	files := parseFlags().sourceOptions.markdownFiles
+	if len(files) != 2 || files[0] != "a.md" || files[1] != "b.md" {
+		t.Errorf("Expected [a.md b.md], got %v", files)
+	}
+}
+
+func TestParseFlagsFromSelectsJSONSource(t *testing.T) {
+	// Reset flag.CommandLine to avoid conflicts with other tests
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+
+	os.Args = []string{"sift", "--from", "-", "--emit", "text"}
+
+	opts := parseFlags()
+	if opts.source != "json" || opts.sourceOptions.from != "-" || opts.emit != "text" {
+		t.Errorf("Expected json source from stdin emitting text, got %+v", opts)
+	}
+}
+
+func TestParseFlagsCommandTimeout(t *testing.T) {
+	// Reset flag.CommandLine to avoid conflicts with other tests
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+
+	os.Args = []string{"sift"}
+	if timeout := parseFlags().commandTimeout; timeout != defaultCommandTimeout {
+		t.Errorf("Expected default timeout %v, got %v", defaultCommandTimeout, timeout)
+	}
+
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	os.Args = []string{"sift", "--command-timeout", "30"}
+	if timeout := parseFlags().commandTimeout; timeout != 30*time.Second {
+		t.Errorf("Expected timeout 30s, got %v", timeout)
+	}
+}
+
+func TestParseFlagsRejectsCommandTimeoutBelowOne(t *testing.T) {
+	// Keep going after the error, so the test can see it.
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	var output bytes.Buffer
+	flag.CommandLine.SetOutput(&output)
+
+	os.Args = []string{"sift", "--command-timeout", "0"}
+	if timeout := parseFlags().commandTimeout; timeout != defaultCommandTimeout {
+		t.Errorf("Expected the default timeout %v, got %v", defaultCommandTimeout, timeout)
+	}
+	if !strings.Contains(output.String(), "must be at least 1") {
+		t.Errorf("Expected an error for a timeout of 0, got %q", output.String())
+	}
+}
+
+func TestParseFlagsRanking(t *testing.T) {
+	// Reset flag.CommandLine to avoid conflicts with other tests
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+
+	os.Args = []string{"sift"}
+	if ranking := parseFlags().ranking; ranking != "tree" {
+		t.Errorf("Expected default ranking tree, got %s", ranking)
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// markdownSource reads tasks from checklists in Markdown files:
+//
+//	# Project
+//	- [ ] An open task
+//	- [x] A completed task
+//	- [-] A canceled task
+//
+// Every task gets the text of the closest heading above it as its project.
+type markdownSource struct {
+	paths []string
+}
+
+func newMarkdownSource(opts sourceOptions) (TaskSource, error) {
+	if len(opts.markdownFiles) == 0 {
+		return nil, errors.New("the markdown source needs at least one --markdown-file")
+	}
+	var paths []string
+	for _, path := range opts.markdownFiles {
+		path, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return markdownSource{paths: paths}, nil
+}
+
+func (s markdownSource) ID() string {
+	return "markdown:" + strings.Join(s.paths, ",")
+}
+
+func (s markdownSource) Capabilities() sourceCapabilities {
+	return sourceCapabilities{
+		Watch:         s.paths,
+		WriteStatuses: []string{StatusCompleted, StatusCanceled},
+		CreateTasks:   true,
+		RenameTasks:   true,
+	}
+}
+
+func (s markdownSource) Fetch() ([]task, error) {
+	var tasks []task
+	for _, path := range s.paths {
+		lines, err := readLines(path)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, parseMarkdownTasks(path, lines)...)
+	}
+	return tasks, nil
+}
+
+var (
+	markdownHeading = regexp.MustCompile(`^#{1,6}\s+(.*?)\s*#*\s*$`)
+	markdownItem    = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s+\[([ xX-])\]\s+(.*)$`)
+	markdownFence   = regexp.MustCompile("^\\s*(```|~~~)")
+	// markdownBlockID matches a block reference like ^abc123 at the end of an
+	// item, as used by Obsidian.
+	markdownBlockID = regexp.MustCompile(`\s+\^([A-Za-z0-9-]+)$`)
+)
+
+// parseMarkdownTasks returns the checklist items in the lines of the Markdown
+// file at path.
+//
+// Items with a block reference (^id) use it as their ID, so they keep it even
+// when their text is edited. Other items get an ID derived from the file and
+// their text, which survives lines being added, removed, or moved around them.
+func parseMarkdownTasks(path string, lines []string) []task {
+	tasks, _ := parseMarkdownLines(path, lines)
+	return tasks
+}
+
+// parseMarkdownLines is like parseMarkdownTasks, but also returns the index of
+// the line of every task.
+func parseMarkdownLines(path string, lines []string) ([]task, []int) {
+	var tasks []task
+	var lineIndexes []int
+	ids := newStableIDs()
+	heading := ""
+	inFence := false
+	for i, line := range lines {
+		if markdownFence.MatchString(line) {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		if m := markdownHeading.FindStringSubmatch(line); m != nil {
+			heading = m[1]
+			continue
+		}
+		m := markdownItem.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		name := strings.TrimSpace(m[2])
+		var id string
+		if b := markdownBlockID.FindStringSubmatch(name); b != nil {
+			id = path + "#^" + b[1]
+			name = strings.TrimSpace(name[:len(name)-len(b[0])])
+		} else {
+			id = ids.next(path + "\x00" + name)
+		}
+		if name == "" {
+			continue
+		}
+
+		status := StatusOpen
+		switch m[1] {
+		case "x", "X":
+			status = StatusCompleted
+		case "-":
+			status = StatusCanceled
+		}
+		tasks = append(tasks, task{
+			ID:      id,
+			Name:    name,
+			Status:  status,
+			Project: heading,
+		})
+		lineIndexes = append(lineIndexes, i)
+	}
+	return tasks, lineIndexes
+}
+
+// markdownCheckboxes maps statuses to the marks of their checkboxes.
+var markdownCheckboxes = map[string]string{
+	StatusOpen:      " ",
+	StatusCompleted: "x",
+	StatusCanceled:  "-",
+}
+
+// SetStatus checks the checkbox of the item with the given ID with x when it's
+// completed, or with - when it's canceled.
+func (s markdownSource) SetStatus(id, status string) error {
+	mark, ok := markdownCheckboxes[status]
+	if !ok {
+		return fmt.Errorf("unknown status %q", status)
+	}
+	for _, path := range s.paths {
+		lines, err := readLines(path)
+		if err != nil {
+			return err
+		}
+		tasks, lineIndexes := parseMarkdownLines(path, lines)
+		for i, t := range tasks {
+			if t.ID != id {
+				continue
+			}
+			line := lines[lineIndexes[i]]
+			box := markdownItem.FindStringSubmatchIndex(line)
+			lines[lineIndexes[i]] = line[:box[2]] + mark + line[box[3]:]
+			return writeLines(path, lines)
+		}
+	}
+	return fmt.Errorf("task %s is not in any of the files", id)
+}
+
+// CreateTask appends an unchecked item to the end of the first file.
+func (s markdownSource) CreateTask(name string) (task, error) {
+	path := s.paths[0]
+	lines, err := readLines(path)
+	if err != nil {
+		return task{}, err
+	}
+	lines = append(lines, "- [ ] "+name)
+	tasks := parseMarkdownTasks(path, lines)
+	if len(tasks) == 0 || tasks[len(tasks)-1].Name != strings.TrimSpace(name) {
+		// The item ended up in a code block that wasn't closed, for example.
+		return task{}, fmt.Errorf("%q can't be added to the end of %s", name, path)
+	}
+	if err := writeLines(path, lines); err != nil {
+		return task{}, err
+	}
+	return tasks[len(tasks)-1], nil
+}
+
+// RenameTask replaces the text of the item with the given ID. Items with a
+// block reference keep it, and with it their ID. Other items get a new ID.
+func (s markdownSource) RenameTask(id, name string) (string, error) {
+	for _, path := range s.paths {
+		lines, err := readLines(path)
+		if err != nil {
+			return "", err
+		}
+		tasks, lineIndexes := parseMarkdownLines(path, lines)
+		for i, t := range tasks {
+			if t.ID != id {
+				continue
+			}
+			line := lines[lineIndexes[i]]
+			item := markdownItem.FindStringSubmatchIndex(line)
+			text := name
+			if b := markdownBlockID.FindString(line[item[4]:]); b != "" {
+				text += b
+			}
+			lines[lineIndexes[i]] = line[:item[4]] + text
+
+			renamed, renamedIndexes := parseMarkdownLines(path, lines)
+			j := slices.Index(renamedIndexes, lineIndexes[i])
+			if j == -1 || renamed[j].Name != strings.TrimSpace(name) {
+				return "", fmt.Errorf("%q is not a valid checklist item", name)
+			}
+			if err := writeLines(path, lines); err != nil {
+				return "", err
+			}
+			return renamed[j].ID, nil
+		}
+	}
+	return "", fmt.Errorf("task %s is not in any of the files", id)
+}
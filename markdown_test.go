@@ -0,0 +1,185 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseMarkdownTasks(t *testing.T) {
+	lines := []string{
+		"# Work",
+		"Some notes about work.",
+		"- [ ] Write report",
+		"  * [x] Send invoice",
+		"1. [-] Book flights",
+		"```",
+		"- [ ] Not a task, it's in a code block",
+		"```",
+		"## Home ##",
+		"- [X] Buy milk",
+		"- [ ]",
+		"- Not a checklist item",
+	}
+
+	tasks := parseMarkdownTasks("/notes/todo.md", lines)
+
+	expected := []struct {
+		name    string
+		status  string
+		project string
+	}{
+		{"Write report", StatusOpen, "Work"},
+		{"Send invoice", StatusCompleted, "Work"},
+		{"Book flights", StatusCanceled, "Work"},
+		{"Buy milk", StatusCompleted, "Home"},
+	}
+	if len(tasks) != len(expected) {
+		t.Fatalf("expected %d tasks, got %d: %+v", len(expected), len(tasks), tasks)
+	}
+	for i, e := range expected {
+		if tasks[i].Name != e.name || tasks[i].Status != e.status || tasks[i].Project != e.project {
+			t.Errorf("task %d: expected %+v, got %+v", i, e, tasks[i])
+		}
+	}
+}
+
+func TestMarkdownIDsSurviveLineEdits(t *testing.T) {
+	before := parseMarkdownTasks("/notes/todo.md", []string{
+		"- [ ] Write report",
+		"- [ ] Buy milk",
+	})
+	after := parseMarkdownTasks("/notes/todo.md", []string{
+		"# Errands",
+		"- [x] Buy milk",
+		"- [ ] A new task",
+		"- [ ] Write report",
+	})
+
+	if before[0].ID != after[2].ID {
+		t.Error("moving a task should not change its ID")
+	}
+	if before[1].ID != after[0].ID {
+		t.Error("checking a task should not change its ID")
+	}
+}
+
+func TestMarkdownBlockIDsSurviveTextEdits(t *testing.T) {
+	before := parseMarkdownTasks("/notes/todo.md", []string{"- [ ] Write report ^report"})
+	after := parseMarkdownTasks("/notes/todo.md", []string{"- [ ] Write the quarterly report ^report"})
+
+	if before[0].ID != after[0].ID {
+		t.Error("editing a task with a block ID should not change its ID")
+	}
+	if after[0].Name != "Write the quarterly report" {
+		t.Errorf("block ID should not be part of the name, got %q", after[0].Name)
+	}
+}
+
+func TestMarkdownIDsAreDistinctAcrossFiles(t *testing.T) {
+	a := parseMarkdownTasks("/notes/a.md", []string{"- [ ] Stretch"})
+	b := parseMarkdownTasks("/notes/b.md", []string{"- [ ] Stretch"})
+
+	if a[0].ID == b[0].ID {
+		t.Error("tasks in different files should have distinct IDs")
+	}
+}
+
+func TestMarkdownSourceFetchesMultipleFiles(t *testing.T) {
+	a := writeTestFile(t, "a.md", "- [ ] First\n")
+	b := writeTestFile(t, "b.md", "- [ ] Second\n- [x] Third\n")
+
+	source, err := newMarkdownSource(sourceOptions{markdownFiles: []string{a, b}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tasks, err := source.Fetch()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tasks) != 3 {
+		t.Errorf("expected 3 tasks, got %d", len(tasks))
+	}
+}
+
+func TestNewMarkdownSourceRequiresFiles(t *testing.T) {
+	if _, err := newMarkdownSource(sourceOptions{}); err == nil {
+		t.Error("expected an error without Markdown files")
+	}
+}
+
+func TestMarkdownSetStatusChecksItem(t *testing.T) {
+	path := writeTestFile(t, "tasks.md", "# Home\n\n- [ ] Buy milk\n  * [ ] Call mom ^mom\n")
+	source := markdownSource{paths: []string{path}}
+	tasks, err := source.Fetch()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := source.SetStatus(tasks[0].ID, StatusCompleted); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := source.SetStatus(tasks[1].ID, StatusCanceled); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if expected := "# Home\n\n- [x] Buy milk\n  * [-] Call mom ^mom\n"; string(data) != expected {
+		t.Errorf("expected %q, got %q", expected, string(data))
+	}
+
+	if err := source.SetStatus("missing", StatusCompleted); err == nil {
+		t.Error("expected an error for a missing task")
+	}
+}
+
+func TestMarkdownCreateTaskAppendsItem(t *testing.T) {
+	path := writeTestFile(t, "tasks.md", "# Home\n\n- [ ] Buy milk\n")
+	source := markdownSource{paths: []string{path}}
+
+	created, err := source.CreateTask("Call mom")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if expected := "# Home\n\n- [ ] Buy milk\n- [ ] Call mom\n"; string(data) != expected {
+		t.Errorf("expected %q, got %q", expected, string(data))
+	}
+	tasks, _ := source.Fetch()
+	if created.ID != tasks[1].ID || created.Project != "Home" {
+		t.Errorf("expected the created task to match the fetched one, got %+v and %+v", created, tasks[1])
+	}
+}
+
+func TestMarkdownCreateTaskRejectsUnclosedCodeBlock(t *testing.T) {
+	path := writeTestFile(t, "tasks.md", "```\n- [ ] Not a task\n")
+	source := markdownSource{paths: []string{path}}
+
+	if _, err := source.CreateTask("Call mom"); err == nil {
+		t.Error("expected an error for an item that ends up in a code block")
+	}
+}
+
+func TestMarkdownRenameTask(t *testing.T) {
+	path := writeTestFile(t, "tasks.md", "- [ ] Buy milk\n- [x] Call mom ^mom\n")
+	source := markdownSource{paths: []string{path}}
+	tasks, _ := source.Fetch()
+
+	newID, err := source.RenameTask(tasks[0].ID, "Buy oat milk")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	blockID, err := source.RenameTask(tasks[1].ID, "Call mom back")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if expected := "- [ ] Buy oat milk\n- [x] Call mom back ^mom\n"; string(data) != expected {
+		t.Errorf("expected %q, got %q", expected, string(data))
+	}
+	renamed, _ := source.Fetch()
+	if newID != renamed[0].ID || newID == tasks[0].ID {
+		t.Errorf("expected the new ID %s, got %s", renamed[0].ID, newID)
+	}
+	if blockID != tasks[1].ID {
+		t.Errorf("expected the block reference to keep the ID %s, got %s", tasks[1].ID, blockID)
+	}
+}
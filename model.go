@@ -1,15 +1,25 @@
 package main
 
 import (
-	"math/rand"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/cursor"
 	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
 type model struct {
+	// source is where tasks are fetched from.
+	source TaskSource
+	// ranker decides which tasks are compared and how they're ranked.
+	ranker   Ranker
 	allTasks []task
 	// taskA and taskB are the tasks that are currently being compared. They will
 	// be nil until the tasks are fetched.
@@ -24,17 +34,107 @@ type model struct {
 	viewport       viewport.Model
 	help           help.Model
 	keys           KeyMap
+	// showDetails is true when the details of the compared tasks are shown.
+	showDetails bool
+	// fetchSeq is the sequence number of the latest fetch, which is the only
+	// one whose results are used.
+	fetchSeq int
+	// fetching is true while the latest fetch is running, so that refreshes
+	// don't start another one.
+	fetching bool
+	// lastSync is when tasks were last fetched successfully.
+	lastSync time.Time
+	// fetchErr is the error of the latest fetch, or nil if it succeeded.
+	fetchErr error
+	// fetchFailures counts the fetches that failed in a row.
+	fetchFailures int
+	// retryAt is when refreshing resumes after fetchFailures failed fetches.
+	retryAt time.Time
+	// watcher reports changes to the files of the source, or is nil if they
+	// aren't watched.
+	watcher *sourceWatcher
+	// stale is true while the tasks are the cached ones of a previous run, and
+	// the source hasn't been fetched yet.
+	stale bool
+	// fingerprint identifies the tasks of the latest fetch that were synced.
+	fingerprint string
+	// loaded is true once the stored relationships were applied at startup.
+	loaded bool
+	// focusIDs are the sorted IDs of the top tasks that were last tagged in
+	// the source, if focusTagged is true.
+	focusIDs    []string
+	focusTagged bool
+	// taggingIDs are the sorted IDs of the top tasks that are being tagged,
+	// if tagging is true.
+	taggingIDs []string
+	tagging    bool
+	// writes counts the status writes to the source that are still running.
+	// Fetching waits for them, so fetched tasks never predate them.
+	writes int
+	// writeErr is the error of the latest failed write or snooze, until the
+	// next key press.
+	writeErr error
+	// prompt is what input is shown for, or promptNone while it's hidden.
+	prompt promptKind
+	input  textinput.Model
+	// promptTaskID is the ID of the task that the rename, snooze, or close
+	// prompt is for.
+	promptTaskID string
+	// closeStatus is the status that the close prompt sets the task to.
+	closeStatus string
+	// snoozed maps the IDs of snoozed tasks to when they wake up.
+	snoozed map[string]time.Time
+	// changedWhileFetching is true when the files of the source changed while
+	// the latest fetch was running, so it may have missed the change.
+	changedWhileFetching bool
+}
+
+// promptKind is what the text input at the bottom is shown for.
+type promptKind int
+
+const (
+	promptNone promptKind = iota
+	// promptAdd asks for the name of a new task.
+	promptAdd
+	// promptRename asks for the new name of a task.
+	promptRename
+	// promptSnooze asks until when a task is snoozed.
+	promptSnooze
+	// promptClose asks to confirm completing or canceling a task.
+	promptClose
+)
+
+// now returns the current time. Tests replace it to control the clock.
+var now = time.Now
+
+// maxFetchBackoff is the longest time refreshing pauses after failed fetches.
+const maxFetchBackoff = 5 * time.Minute
+
+// fetchBackoff returns how long to wait before fetching again after the given
+// number of failed fetches in a row. The wait doubles with every failure.
+func fetchBackoff(failures int) time.Duration {
+	backoff := max(refreshInterval, time.Second)
+	for i := 1; i < failures && backoff < maxFetchBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxFetchBackoff {
+		return maxFetchBackoff
+	}
+	return backoff
 }
 
 // Decision represents the decision that was made, where childID is the ID of
 // the task that we assigned a parent to, previousParentID is the ID of the
 // child's parent before the decision, and taskAID and taskBID are the tasks
-// that existed as choices at the time of the decision.
+// that existed as choices at the time of the decision. belowID is the ID of a
+// task that was moved below the child along with it, whose parent was the
+// child's new parent, or empty.
 type decision struct {
 	childID          string
 	previousParentID string
 	taskAID          string
 	taskBID          string
+	belowID          string
 }
 
 func initialModel() model {
@@ -46,6 +146,11 @@ func initialModel() model {
 	helpModel.Styles.FullDesc = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
 	helpModel.Styles.FullSeparator = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
 
+	input := textinput.New()
+	input.PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("7"))
+	input.PlaceholderStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	input.Cursor.SetMode(cursor.CursorStatic)
+
 	return model{
 		allTasks:       []task{},
 		highlightIndex: 0,
@@ -54,90 +159,98 @@ func initialModel() model {
 		viewport:       viewport.New(0, 0),
 		help:           helpModel,
 		keys:           DefaultKeyMap,
+		input:          input,
+		ranker:         newTreeRanker(),
 	}
 }
 
+// Init loads the snoozes, shows the cached tasks, fetches the tasks, and loads
+// their relationships. The refresh timer starts once that's done, so the first
+// refresh can't overlap with it.
 func (m model) Init() tea.Cmd {
-	return tea.Batch(
-		tea.Sequence(
-			getTasksFromThings,
-			func() tea.Msg { return loadRelationshipsMsg{} },
-		),
-		getFetchTick(),
+	return tea.Sequence(
+		loadSnoozes(),
+		loadCachedTasks(),
+		fetchTasks(m.source, m.fetchSeq),
+		func() tea.Msg { return loadRelationshipsMsg{} },
 	)
 }
 
-func (m model) comparisonTasksNeedUpdated() bool {
-	allTasksMap := make(map[string]task)
-	for _, t := range m.allTasks {
-		allTasksMap[t.ID] = t
+// startFetch returns the command that starts a new fetch. Results of fetches
+// that are still running are discarded once it's started.
+func (m *model) startFetch() tea.Cmd {
+	m.fetchSeq++
+	m.fetching = true
+	return fetchTasks(m.source, m.fetchSeq)
+}
+
+// nextFetchTick returns the command that starts the next refresh timer, or nil
+// if the source doesn't need to be polled. Sources with files are polled too
+// when the files can't be watched.
+func (m model) nextFetchTick() tea.Cmd {
+	if m.source == nil {
+		return nil
 	}
-	tasksByLevel := assignLevels(m.allTasks)
-	i := getHighestLevelWithMultipleTasks(tasksByLevel)
-	if i == -1 {
-		return false
+	capabilities := m.source.Capabilities()
+	if !capabilities.Polling && (len(capabilities.Watch) == 0 || m.watcher != nil) {
+		return nil
+	}
+	return getFetchTick()
+}
+
+// waitForChange returns the command that waits for the next change to the
+// files of the source, or nil if they aren't watched.
+func (m model) waitForChange() tea.Cmd {
+	if m.watcher == nil {
+		return nil
 	}
-	highestLevel := tasksByLevel[i]
+	return m.watcher.waitForChange()
+}
 
-	highestLevelTasksMap := make(map[string]task)
-	for _, t := range highestLevel {
-		highestLevelTasksMap[t.ID] = t
+// fetchIfChanged returns the command that fetches again if the files of the
+// source changed during the fetch that just finished, or nil otherwise.
+func (m *model) fetchIfChanged() tea.Cmd {
+	if !m.changedWhileFetching {
+		return nil
 	}
-	// If there are no levels with multiple tasks and comparison tasks are nil,
-	// then no update is needed (the hierarchy is complete)
-	if highestLevel == nil && m.taskA == nil && m.taskB == nil {
-		return false
+	m.changedWhileFetching = false
+	return m.startFetch()
+}
+
+func (m model) comparisonTasksNeedUpdated() bool {
+	if ranked, total := m.ranker.Progress(m.allTasks); ranked == total {
+		// Every task is ranked, so there's nothing to compare. Tasks that
+		// are still shown, e.g. because one of them was just closed, need
+		// to go.
+		return m.taskA != nil || m.taskB != nil
 	}
-	if m.taskA == nil ||
-		m.taskB == nil ||
-		m.taskA.isFullyPrioritized(m.allTasks) ||
-		m.taskB.isFullyPrioritized(m.allTasks) {
+	if m.taskA == nil || m.taskB == nil {
 		return true
 	}
-	// If the taskA or taskB are not in the map, then they need to be updated.
-	allTasksTaskA, ok := allTasksMap[m.taskA.ID]
-	if !ok {
+	// If the taskA or taskB are not in allTasks, then they need to be updated.
+	allTasksTaskA := getTaskByID(m.taskA.ID, m.allTasks)
+	if allTasksTaskA == nil {
 		return true
 	}
 	// If the names of the tasks are different, then they need to be updated.
 	if m.taskA.Name != allTasksTaskA.Name {
 		return true
 	}
-	allTasksTaskB, ok := allTasksMap[m.taskB.ID]
-	if !ok {
+	allTasksTaskB := getTaskByID(m.taskB.ID, m.allTasks)
+	if allTasksTaskB == nil {
 		return true
 	}
 	if m.taskB.Name != allTasksTaskB.Name {
 		return true
 	}
-	// If taskA or taskB aren't at the highest unprioritized level, then they
-	// need to be updated.
-	_, ok = highestLevelTasksMap[m.taskA.ID]
-	if !ok {
-		return true
-	}
-	_, ok = highestLevelTasksMap[m.taskB.ID]
-	return !ok
+	// If the ranker wouldn't compare taskA and taskB anymore, then they need
+	// to be updated.
+	return !m.ranker.CanCompare(m.allTasks, *allTasksTaskA, *allTasksTaskB)
 }
 
 // Updates the model with the tasks that are currently being compared.
 func (m *model) updateComparisonTasks() *model {
-	tasksByLevel := assignLevels(m.allTasks)
-	i := getHighestLevelWithMultipleTasks(tasksByLevel)
-	if i == -1 {
-		// There are no levels with multiple tasks.
-		m.taskA = nil
-		m.taskB = nil
-		return m
-	}
-	highestLevel := tasksByLevel[i]
-	m.taskA = &highestLevel[rand.Intn(len(highestLevel))]
-	// Make sure the tasks aren't the same.
-	m.taskB = m.taskA
-	// TODO: Make it so we're not just trying rand over and over again.
-	for m.taskB == m.taskA {
-		m.taskB = &highestLevel[rand.Intn(len(highestLevel))]
-	}
+	m.taskA, m.taskB = m.ranker.NextPair(m.allTasks)
 	Logger.Debugf("Updated comparison tasks: %+v", m.taskA)
 	Logger.Debugf("Updated comparison tasks: %+v", m.taskB)
 	return m
@@ -146,34 +259,376 @@ func (m *model) updateComparisonTasks() *model {
 // updateComparisonTasksWithPreference attempts to restore preferred tasks,
 // falls back to existing random selection if not possible
 func (m *model) updateComparisonTasksWithPreference(preferredAID, preferredBID string) *model {
-	tasksByLevel := assignLevels(m.allTasks)
-	i := getHighestLevelWithMultipleTasks(tasksByLevel)
-
-	if i != -1 {
-		// Try to find both preferred tasks at the highest unprioritized level
-		taskA := getTaskByID(preferredAID, tasksByLevel[i])
-		taskB := getTaskByID(preferredBID, tasksByLevel[i])
-		if taskA != nil && taskB != nil {
-			m.taskA = taskA
-			m.taskB = taskB
-			return m
-		}
+	taskA := getTaskByID(preferredAID, m.allTasks)
+	taskB := getTaskByID(preferredBID, m.allTasks)
+	if taskA != nil && taskB != nil && m.ranker.CanCompare(m.allTasks, *taskA, *taskB) {
+		// Copy the tasks, so they don't change with allTasks.
+		a, b := *taskA, *taskB
+		m.taskA = &a
+		m.taskB = &b
+		return m
 	}
 
-	// Fallback to existing random selection logic
+	// Fallback to the next pair of the ranker
 	return m.updateComparisonTasks()
 }
 
+// chooseTask ranks the compared task with the ID winnerID above the other one,
+// and moves on to the next comparison.
+func (m *model) chooseTask(winnerID, loserID string) {
+	winner := getTaskByID(winnerID, m.allTasks)
+	loser := getTaskByID(loserID, m.allTasks)
+	if winner == nil || loser == nil || winner.getLevel(m.allTasks) == -1 || loser.getLevel(m.allTasks) == -1 {
+		// The tasks changed since they were compared, e.g. one of them was
+		// closed or snoozed.
+		return
+	}
+	d := m.ranker.Record(m.allTasks, winnerID, loserID)
+	d.taskAID = m.taskA.ID
+	d.taskBID = m.taskB.ID
+	*m = m.addDecisionToHistory(d)
+	m.updateComparisonTasks()
+}
+
+// rankedTasks returns the tasks that are ranked, from the highest priority to
+// the lowest.
+func (m model) rankedTasks() []task {
+	ranked, _ := m.ranker.Ordered(m.allTasks)
+	return ranked
+}
+
+// writePrioritiesIfComplete returns a command that writes the ranking to the
+// source once every open task is fully prioritized, if the source supports it
+// and the user opted in. Otherwise it returns nil.
+func (m model) writePrioritiesIfComplete() tea.Cmd {
+	writer, ok := m.source.(priorityWriter)
+	if !ok || !m.source.Capabilities().WritePriorities {
+		return nil
+	}
+	if m.stale {
+		// The cached tasks may have changed in the source since.
+		return nil
+	}
+	if ranked, total := m.ranker.Progress(m.allTasks); ranked != total {
+		// There are still tasks to compare.
+		return nil
+	}
+	ordered := m.rankedTasks()
+	if len(ordered) == 0 {
+		return nil
+	}
+	return writePriorities(writer, ordered)
+}
+
+// canSetStatus reports whether the source can set tasks to status.
+func (m model) canSetStatus(status string) bool {
+	if _, ok := m.source.(statusWriter); !ok {
+		return false
+	}
+	return slices.Contains(m.source.Capabilities().WriteStatuses, status)
+}
+
+// openClosePrompt asks to confirm setting a task to status, if the source
+// supports it. The prompt starts with the first of closeTargets.
+func (m *model) openClosePrompt(status string) tea.Cmd {
+	if !m.canSetStatus(status) {
+		return nil
+	}
+	targets := m.closeTargets()
+	if len(targets) == 0 {
+		return nil
+	}
+	m.closeStatus = status
+	m.promptTaskID = targets[0].ID
+	return m.openPrompt(
+		promptClose,
+		closeQuestion(status, targets[0]),
+		"enter to confirm, tab for the next task, esc to keep it",
+		"",
+	)
+}
+
+// closeTargets returns the tasks that the close prompt can be for: the top
+// task, followed by the compared tasks.
+func (m model) closeTargets() []task {
+	var targets []task
+	if ranked := m.rankedTasks(); len(ranked) > 0 {
+		targets = append(targets, ranked[0])
+	}
+	for _, compared := range []*task{m.taskA, m.taskB} {
+		if compared != nil && getTaskByID(compared.ID, targets) == nil {
+			targets = append(targets, *compared)
+		}
+	}
+	return targets
+}
+
+// nextCloseTarget moves the close prompt to the task after the current one in
+// closeTargets.
+func (m *model) nextCloseTarget() {
+	targets := m.closeTargets()
+	if len(targets) == 0 {
+		return
+	}
+	next := targets[0]
+	for i, t := range targets {
+		if t.ID == m.promptTaskID {
+			next = targets[(i+1)%len(targets)]
+		}
+	}
+	m.promptTaskID = next.ID
+	m.input.Prompt = closeQuestion(m.closeStatus, next)
+}
+
+// closeQuestion returns the question of the close prompt for t.
+func closeQuestion(status string, t task) string {
+	verb := "Complete"
+	if status == StatusCanceled {
+		verb = "Cancel"
+	}
+	return fmt.Sprintf("%s %q? ", verb, t.Name)
+}
+
+// setTaskStatus returns a command that completes or cancels the task with the
+// given ID in the source. The task is closed in allTasks right away, and
+// restored if the source fails to close it. It returns nil if the task isn't
+// open anymore, or if the source can't set tasks to status.
+func (m *model) setTaskStatus(id, status string) tea.Cmd {
+	if !m.canSetStatus(status) {
+		return nil
+	}
+	writer := m.source.(statusWriter)
+	if t := getTaskByID(id, m.allTasks); t == nil || t.Status != StatusOpen {
+		return nil
+	}
+
+	updated := slices.Clone(m.allTasks)
+	getTaskByID(id, updated).Status = status
+	// Syncing reassigns the children of the closed task, as if it had been
+	// closed in the source.
+	merged := syncTasks(m.allTasks, updated)
+	var previous []task
+	for _, t := range m.allTasks {
+		if after := getTaskByID(t.ID, merged); after != nil &&
+			(after.Status != t.Status || !slices.Equal(parentIDs(*after), parentIDs(t))) {
+			previous = append(previous, t)
+		}
+	}
+	m.allTasks = merged
+	if m.comparisonTasksNeedUpdated() {
+		m.updateComparisonTasks()
+	}
+
+	m.beginWrite()
+	return setStatus(writer, id, status, previous)
+}
+
+// beginWrite discards the running fetch, which may have read the tasks before
+// the write, and holds off fetching until the write is done.
+func (m *model) beginWrite() {
+	m.fetchSeq++
+	m.fetching = false
+	// Make sure the next fetch is synced, even if the write didn't change
+	// the tasks in the source.
+	m.fingerprint = ""
+	m.writes++
+}
+
+// endWrite returns the command that fetches the tasks once every write is
+// done, or nil while some are still running.
+func (m *model) endWrite() tea.Cmd {
+	m.writes--
+	if m.writes > 0 || m.source == nil {
+		return nil
+	}
+	return m.startFetch()
+}
+
+// openAddPrompt shows the prompt for a new task, if the source can create
+// tasks.
+func (m *model) openAddPrompt() tea.Cmd {
+	if _, ok := m.source.(taskCreator); !ok || !m.source.Capabilities().CreateTasks {
+		return nil
+	}
+	return m.openPrompt(promptAdd, "Add task: ", "enter to add, esc to cancel", "")
+}
+
+// openRenamePrompt shows the prompt for the new name of t, if the source can
+// rename tasks.
+func (m *model) openRenamePrompt(t *task) tea.Cmd {
+	if t == nil {
+		return nil
+	}
+	if _, ok := m.source.(taskRenamer); !ok || !m.source.Capabilities().RenameTasks {
+		return nil
+	}
+	m.promptTaskID = t.ID
+	return m.openPrompt(promptRename, "Rename: ", "enter to rename, esc to cancel", t.Name)
+}
+
+// openPrompt shows the input with the given prompt, placeholder, and value.
+func (m *model) openPrompt(kind promptKind, prompt, placeholder, value string) tea.Cmd {
+	m.prompt = kind
+	m.input.Prompt = prompt
+	m.input.Placeholder = placeholder
+	m.input.SetValue(value)
+	m.input.CursorEnd()
+	cmd := m.input.Focus()
+	m.viewport.Height = m.height - lipgloss.Height(m.helpView())
+	return cmd
+}
+
+// closePrompt hides the input.
+func (m *model) closePrompt() {
+	m.prompt = promptNone
+	m.input.Blur()
+	m.viewport.Height = m.height - lipgloss.Height(m.helpView())
+}
+
+// submitPrompt closes the prompt and returns the command that creates,
+// renames, snoozes, or closes the task, or nil if there's nothing to do.
+func (m *model) submitPrompt() tea.Cmd {
+	name := strings.TrimSpace(m.input.Value())
+	kind := m.prompt
+	m.closePrompt()
+	if kind == promptClose {
+		cmd := m.setTaskStatus(m.promptTaskID, m.closeStatus)
+		if cmd == nil {
+			return nil
+		}
+		return tea.Batch(cmd, storeTasks(m.allTasks), m.tagFocusIfChanged())
+	}
+	if name == "" {
+		return nil
+	}
+	switch kind {
+	case promptAdd:
+		m.beginWrite()
+		return createTask(m.source.(taskCreator), name)
+	case promptRename:
+		return m.renameTask(m.promptTaskID, name)
+	case promptSnooze:
+		return m.snoozeTask(m.promptTaskID, name)
+	}
+	return nil
+}
+
+// renameTask returns the command that renames the task in the source. The
+// task is renamed in allTasks right away, along with the compared tasks, so
+// the comparison stays the same. It returns nil if the task is gone or keeps
+// its name.
+func (m *model) renameTask(id, name string) tea.Cmd {
+	t := getTaskByID(id, m.allTasks)
+	if t == nil || t.Name == name {
+		return nil
+	}
+	previousName := t.Name
+	m.setTaskName(id, name)
+	m.beginWrite()
+	return renameTask(m.source.(taskRenamer), id, name, previousName)
+}
+
+// setTaskName changes the name of the task with the given ID in allTasks and
+// in the compared tasks.
+func (m *model) setTaskName(id, name string) {
+	if t := getTaskByID(id, m.allTasks); t != nil {
+		t.Name = name
+	}
+	for _, compared := range []*task{m.taskA, m.taskB} {
+		if compared != nil && compared.ID == id {
+			compared.Name = name
+		}
+	}
+}
+
+// replaceTaskID gives the task the new ID that the source gave it, in allTasks,
+// the parents of its children, the compared tasks, and the history.
+func (m *model) replaceTaskID(id, newID string) {
+	for i := range m.allTasks {
+		if m.allTasks[i].ID == id {
+			m.allTasks[i].ID = newID
+		}
+		if m.allTasks[i].ParentID != nil && *m.allTasks[i].ParentID == id {
+			m.allTasks[i].ParentID = &newID
+		}
+	}
+	for _, compared := range []*task{m.taskA, m.taskB} {
+		if compared != nil && compared.ID == id {
+			compared.ID = newID
+		}
+	}
+	m.ranker.ReplaceID(id, newID)
+	for i := range m.history {
+		for _, field := range []*string{
+			&m.history[i].childID,
+			&m.history[i].previousParentID,
+			&m.history[i].taskAID,
+			&m.history[i].taskBID,
+			&m.history[i].belowID,
+		} {
+			if *field == id {
+				*field = newID
+			}
+		}
+	}
+}
+
+// parentIDs returns the parent ID of t as a slice, so that parents can be
+// compared with slices.Equal.
+func parentIDs(t task) []string {
+	if t.ParentID == nil {
+		return nil
+	}
+	return []string{*t.ParentID}
+}
+
+// tagFocusIfChanged returns a command that tags the top fully prioritized tasks
+// in the source when they changed since they were last tagged, if the source
+// supports it and the user opted in. Otherwise it returns nil.
+func (m *model) tagFocusIfChanged() tea.Cmd {
+	tagger, ok := m.source.(focusTagger)
+	if !ok || !m.loaded || m.stale {
+		// Until the relationships are loaded, the top isn't known yet.
+		return nil
+	}
+	count := m.source.Capabilities().FocusCount
+	if count == 0 {
+		return nil
+	}
+	top := m.rankedTasks()
+	top = top[:min(count, len(top))]
+	ids := make([]string, len(top))
+	for i, t := range top {
+		ids[i] = t.ID
+	}
+	// Every top task gets the same tag, so their order doesn't matter.
+	sort.Strings(ids)
+	if m.tagging && slices.Equal(ids, m.taggingIDs) {
+		// They're being tagged already.
+		return nil
+	}
+	if !m.tagging && m.focusTagged && slices.Equal(ids, m.focusIDs) {
+		return nil
+	}
+	m.taggingIDs = ids
+	m.tagging = true
+	return tagFocus(tagger, top, ids)
+}
+
 // addToHistory adds a decision to the history, maintaining max 10 items
 func (m model) addToHistory(childID, previousParentID, taskAID, taskBID string) model {
-	decision := decision{
+	return m.addDecisionToHistory(decision{
 		childID:          childID,
 		previousParentID: previousParentID, // empty string for nil
 		taskAID:          taskAID,
 		taskBID:          taskBID,
-	}
+	})
+}
 
-	m.history = append(m.history, decision)
+// addDecisionToHistory adds a decision to the history, maintaining max 10
+// items
+func (m model) addDecisionToHistory(d decision) model {
+	m.history = append(m.history, d)
 
 	// Keep only last 10 items
 	if len(m.history) > 10 {
@@ -208,10 +663,11 @@ func (m model) canUndo() bool {
 		return true
 	}
 
-	// Check if previous parent still exists and is available (not completed/canceled)
+	// Check if previous parent still exists and is available (not completed,
+	// canceled, or snoozed)
 	for _, task := range m.allTasks {
 		if task.ID == lastDecision.previousParentID {
-			if task.Status == StatusCompleted || task.Status == StatusCanceled {
+			if task.Status == StatusCompleted || task.Status == StatusCanceled || task.Snoozed {
 				return false // Previous parent is no longer available
 			}
 			return true // Previous parent exists and is available
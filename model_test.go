@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"testing"
+	"time"
 )
 
 func TestInitialModelHasNoTasks(t *testing.T) {
@@ -651,6 +652,25 @@ func TestCanUndoReturnsFalseWhenPreviousParentCanceled(t *testing.T) {
 	}
 }
 
+func TestCanUndoReturnsFalseWhenPreviousParentSnoozed(t *testing.T) {
+	m := initialModel()
+
+	// Set up tasks: previous parent (snoozed), current parent, and child
+	previousParentTask := CreateTestTask("previous-parent", "Previous Parent Task", "")
+	previousParentTask.Snoozed = true
+	currentParentTask := CreateTestTask("current-parent", "Current Parent Task", "")
+	childTask := CreateTestTask("child", "Child Task", "current-parent")
+	m.allTasks = []task{previousParentTask, currentParentTask, childTask}
+
+	// Add decision to history that moved child from previous-parent to current-parent
+	m = m.addToHistory("child", "previous-parent", "current-parent", "other")
+
+	// Should not be able to undo because previous parent is hidden
+	if m.canUndo() {
+		t.Error("Should not be able to undo when previous parent is snoozed")
+	}
+}
+
 func TestCanUndoReturnsTrueForValidHistory(t *testing.T) {
 	m := initialModel()
 
@@ -698,3 +718,43 @@ func TestCanUndoReturnsFalseWhenChildDeleted(t *testing.T) {
 		t.Error("Should not be able to undo when child is deleted")
 	}
 }
+
+func TestFetchBackoffDoublesUpToMaximum(t *testing.T) {
+	original := refreshInterval
+	defer func() { refreshInterval = original }()
+	refreshInterval = 3 * time.Second
+
+	expected := []time.Duration{3 * time.Second, 6 * time.Second, 12 * time.Second, 24 * time.Second}
+	for i, e := range expected {
+		if got := fetchBackoff(i + 1); got != e {
+			t.Errorf("backoff after %d failures: expected %v, got %v", i+1, e, got)
+		}
+	}
+	if got := fetchBackoff(100); got != maxFetchBackoff {
+		t.Errorf("expected backoff to be capped at %v, got %v", maxFetchBackoff, got)
+	}
+}
+
+func TestFetchBackoffHasMinimumForZeroRefreshInterval(t *testing.T) {
+	original := refreshInterval
+	defer func() { refreshInterval = original }()
+	refreshInterval = 0
+
+	if got := fetchBackoff(1); got != time.Second {
+		t.Errorf("expected a backoff of 1s, got %v", got)
+	}
+}
+
+func TestWatchedSourcesArePolledWithoutWatcher(t *testing.T) {
+	m := CreateTestModel(CreateTestTasks(3))
+	source := m.source.(*fakeSource)
+	source.capabilities = sourceCapabilities{Watch: []string{"todo.txt"}}
+
+	if m.nextFetchTick() == nil {
+		t.Error("expected polling when the files aren't watched")
+	}
+	m.watcher = &sourceWatcher{changes: make(chan struct{})}
+	if m.nextFetchTick() != nil {
+		t.Error("expected no polling when the files are watched")
+	}
+}
@@ -1,15 +1,88 @@
 package main
 
-// fetchMsg is a message that signals that the tasks should be fetched from Things.
+import "time"
+
+// fetchMsg is a message that signals that the tasks should be fetched from the
+// source.
 type fetchMsg struct{}
 
+// sourceChangedMsg signals that the files of the source have changed.
+type sourceChangedMsg struct{}
+
 // tasksMsg shares a list of tasks.
 type tasksMsg struct {
 	Tasks []task
+	// Seq is the sequence number of the fetch that got the tasks.
+	Seq int
+	// Fingerprint identifies the fetched tasks, see fingerprintTasks. It's
+	// empty when the tasks weren't fetched from a source.
+	Fingerprint string
+}
+
+// cachedTasksMsg shares the tasks of the last fetch of a previous run, which are
+// shown until the source has been fetched.
+type cachedTasksMsg struct {
+	Tasks []task
+}
+
+// snoozesMsg shares the stored snoozes, which map task IDs to when the tasks
+// wake up.
+type snoozesMsg struct {
+	Snoozed map[string]time.Time
+}
+
+// snoozeExpiredMsg signals that a snoozed task may have to wake up.
+type snoozeExpiredMsg struct{}
+
+// fetchErrorMsg is a message that contains the error of a failed fetch.
+type fetchErrorMsg struct {
+	err error
+	// Seq is the sequence number of the fetch that failed.
+	Seq int
+}
+
+func (e fetchErrorMsg) Error() string {
+	return e.err.Error()
 }
 
 type storageSuccessMsg struct{}
 
+// statusWrittenMsg reports whether the status of a task was set in the source.
+// If it wasn't, the tasks in previous are restored.
+type statusWrittenMsg struct {
+	err      error
+	previous []task
+}
+
+// taskCreatedMsg shares the task that was created in the source, or the error
+// if creating it failed.
+type taskCreatedMsg struct {
+	task task
+	err  error
+}
+
+// taskRenamedMsg reports whether the task with the given ID was renamed in the
+// source, and the ID it has since.
+type taskRenamedMsg struct {
+	id           string
+	newID        string
+	previousName string
+	err          error
+}
+
+// focusTaggedMsg signals that the top tasks with the given sorted IDs were
+// tagged in the source, or that tagging them failed with err.
+type focusTaggedMsg struct {
+	ids []string
+	err error
+}
+
+// prioritiesWrittenMsg signals that the ranking was written to the source, or
+// that writing it failed with err.
+type prioritiesWrittenMsg struct {
+	err error
+}
+
 // loadRelationshipsMsg signals that relationships should be loaded from storage.
 type loadRelationshipsMsg struct{}
 
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// sourceKeySeparator separates the key of a source from the ID of one of its
+// tasks in a multiSource, e.g. "things/2Hf8Tz".
+const sourceKeySeparator = "/"
+
+// keyedSource is a source and the key its task IDs are prefixed with.
+type keyedSource struct {
+	key    string
+	source TaskSource
+}
+
+// multiSource merges the tasks of several sources into one list, so they can
+// be ranked against each other. Task IDs are prefixed with the key of their
+// source, so tasks from different sources never share an ID.
+type multiSource struct {
+	sources []keyedSource
+}
+
+// newMultiSource builds a source from every name in the comma-separated list.
+// The names double as the keys of the sources.
+func newMultiSource(names string, opts sourceOptions) (TaskSource, error) {
+	var multi multiSource
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if seen[name] {
+			return nil, fmt.Errorf("source %q is listed more than once", name)
+		}
+		seen[name] = true
+		source, err := newSource(name, opts)
+		if err != nil {
+			return nil, err
+		}
+		multi.sources = append(multi.sources, keyedSource{key: name, source: source})
+	}
+	return multi, nil
+}
+
+func (s multiSource) ID() string {
+	var ids []string
+	for _, ks := range s.sources {
+		ids = append(ids, ks.source.ID())
+	}
+	return strings.Join(ids, "+")
+}
+
+func (s multiSource) Capabilities() sourceCapabilities {
+	var capabilities sourceCapabilities
+	for _, ks := range s.sources {
+		c := ks.source.Capabilities()
+		capabilities.Polling = capabilities.Polling || c.Polling
+		capabilities.Watch = append(capabilities.Watch, c.Watch...)
+		capabilities.WritePriorities = capabilities.WritePriorities || c.WritePriorities
+		for _, status := range c.WriteStatuses {
+			if !slices.Contains(capabilities.WriteStatuses, status) {
+				capabilities.WriteStatuses = append(capabilities.WriteStatuses, status)
+			}
+		}
+		capabilities.CreateTasks = capabilities.CreateTasks || c.CreateTasks
+		capabilities.RenameTasks = capabilities.RenameTasks || c.RenameTasks
+		capabilities.FocusCount = max(capabilities.FocusCount, c.FocusCount)
+	}
+	return capabilities
+}
+
+// Fetch fetches every source at once. If any source fails, the whole fetch
+// fails, since its tasks would otherwise look like they were deleted.
+func (s multiSource) Fetch() ([]task, error) {
+	results := make([][]task, len(s.sources))
+	errs := make([]error, len(s.sources))
+	var wg sync.WaitGroup
+	for i, ks := range s.sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = ks.source.Fetch()
+		}()
+	}
+	wg.Wait()
+
+	var tasks []task
+	for i, ks := range s.sources {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("%s: %w", ks.key, errs[i])
+		}
+		for _, t := range results[i] {
+			t.ID = ks.key + sourceKeySeparator + t.ID
+			tasks = append(tasks, t)
+		}
+	}
+	return tasks, nil
+}
+
+// WritePriorities writes the ranking of each source's own tasks to the sources
+// that have writing priorities enabled.
+func (s multiSource) WritePriorities(ordered []task) error {
+	for _, ks := range s.sources {
+		writer, ok := ks.source.(priorityWriter)
+		if !ok || !ks.source.Capabilities().WritePriorities {
+			continue
+		}
+		if err := writer.WritePriorities(s.tasksOf(ks.key, ordered)); err != nil {
+			return fmt.Errorf("%s: %w", ks.key, err)
+		}
+	}
+	return nil
+}
+
+// TagFocus tags the top tasks of each source in the sources that tag them. A
+// source only tags its own tasks among the top of the merged ranking, so it
+// may tag fewer than its FocusCount.
+func (s multiSource) TagFocus(top []task) error {
+	for _, ks := range s.sources {
+		tagger, ok := ks.source.(focusTagger)
+		count := ks.source.Capabilities().FocusCount
+		if !ok || count == 0 {
+			continue
+		}
+		own := s.tasksOf(ks.key, top[:min(count, len(top))])
+		if err := tagger.TagFocus(own); err != nil {
+			return fmt.Errorf("%s: %w", ks.key, err)
+		}
+	}
+	return nil
+}
+
+// SetStatus sets the status of the task in the source it came from.
+func (s multiSource) SetStatus(id, status string) error {
+	ks, id, err := s.sourceOf(id)
+	if err != nil {
+		return err
+	}
+	writer, ok := ks.source.(statusWriter)
+	if !ok || !slices.Contains(ks.source.Capabilities().WriteStatuses, status) {
+		return fmt.Errorf("%s can't set tasks to %s", ks.key, status)
+	}
+	if err := writer.SetStatus(id, status); err != nil {
+		return fmt.Errorf("%s: %w", ks.key, err)
+	}
+	return nil
+}
+
+// RenameTask renames the task in the source it came from.
+func (s multiSource) RenameTask(id, name string) (string, error) {
+	ks, id, err := s.sourceOf(id)
+	if err != nil {
+		return "", err
+	}
+	renamer, ok := ks.source.(taskRenamer)
+	if !ok || !ks.source.Capabilities().RenameTasks {
+		return "", fmt.Errorf("%s can't rename tasks", ks.key)
+	}
+	newID, err := renamer.RenameTask(id, name)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", ks.key, err)
+	}
+	return ks.key + sourceKeySeparator + newID, nil
+}
+
+// CreateTask creates the task in the first source that can create tasks.
+func (s multiSource) CreateTask(name string) (task, error) {
+	for _, ks := range s.sources {
+		creator, ok := ks.source.(taskCreator)
+		if !ok || !ks.source.Capabilities().CreateTasks {
+			continue
+		}
+		created, err := creator.CreateTask(name)
+		if err != nil {
+			return task{}, fmt.Errorf("%s: %w", ks.key, err)
+		}
+		created.ID = ks.key + sourceKeySeparator + created.ID
+		return created, nil
+	}
+	return task{}, errors.New("none of the sources can create tasks")
+}
+
+// sourceOf returns the source that the task with the given ID came from, and
+// the ID that source knows it by.
+func (s multiSource) sourceOf(id string) (keyedSource, string, error) {
+	for _, ks := range s.sources {
+		if own, ok := strings.CutPrefix(id, ks.key+sourceKeySeparator); ok {
+			return ks, own, nil
+		}
+	}
+	return keyedSource{}, "", fmt.Errorf("no source has the task %s", id)
+}
+
+// tasksOf returns the tasks that belong to the source with the given key, with
+// the IDs that source knows them by.
+func (s multiSource) tasksOf(key string, tasks []task) []task {
+	var own []task
+	for _, t := range tasks {
+		if id, ok := strings.CutPrefix(t.ID, key+sourceKeySeparator); ok {
+			t.ID = id
+			own = append(own, t)
+		}
+	}
+	return own
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func newTestMultiSource() (multiSource, *fakeSource, *fakeSource) {
+	work := newFakeSource([]task{CreateTestTask("1", "Write report", "")})
+	work.id = "work"
+	home := newFakeSource([]task{CreateTestTask("1", "Buy milk", "")})
+	home.id = "home"
+	home.capabilities = sourceCapabilities{Polling: false, WritePriorities: true}
+	return multiSource{sources: []keyedSource{
+		{key: "work", source: work},
+		{key: "home", source: home},
+	}}, work, home
+}
+
+func TestMultiSourceNamespacesIDs(t *testing.T) {
+	multi, _, _ := newTestMultiSource()
+
+	tasks, err := multi.Fetch()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tasks) != 2 || tasks[0].ID != "work/1" || tasks[1].ID != "home/1" {
+		t.Errorf("expected IDs work/1 and home/1, got %+v", tasks)
+	}
+}
+
+func TestMultiSourceFailsWhenAnySourceFails(t *testing.T) {
+	multi, _, home := newTestMultiSource()
+	home.err = errors.New("unavailable")
+
+	if _, err := multi.Fetch(); err == nil {
+		t.Error("expected an error when a source fails")
+	}
+}
+
+func TestMultiSourceCombinesCapabilities(t *testing.T) {
+	multi, _, _ := newTestMultiSource()
+
+	capabilities := multi.Capabilities()
+	if !capabilities.Polling || !capabilities.WritePriorities {
+		t.Errorf("expected polling and writing priorities, got %+v", capabilities)
+	}
+	if statuses := capabilities.WriteStatuses; len(statuses) != 0 {
+		t.Errorf("expected no statuses to write, got %v", statuses)
+	}
+	if multi.ID() != "work+home" {
+		t.Errorf("unexpected ID %s", multi.ID())
+	}
+}
+
+func TestMultiSourceWritesPrioritiesToEachSource(t *testing.T) {
+	multi, work, home := newTestMultiSource()
+	ordered := []task{
+		CreateTestTask("home/1", "Buy milk", ""),
+		CreateTestTask("work/1", "Write report", ""),
+	}
+
+	if err := multi.WritePriorities(ordered); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(work.written) != 0 {
+		t.Error("priorities should not be written to a source without the capability")
+	}
+	if len(home.written) != 1 || len(home.written[0]) != 1 || home.written[0][0].ID != "1" {
+		t.Errorf("expected the home task with its own ID, got %+v", home.written)
+	}
+}
+
+func TestNewSourceBuildsMultiSourceFromList(t *testing.T) {
+	source, err := newSource("things, json", sourceOptions{from: "tasks.json"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	multi, ok := source.(multiSource)
+	if !ok {
+		t.Fatalf("expected a multiSource, got %T", source)
+	}
+	if len(multi.sources) != 2 || multi.sources[0].key != "things" || multi.sources[1].key != "json" {
+		t.Errorf("unexpected sources: %+v", multi.sources)
+	}
+
+	if _, err := newSource("things,things", sourceOptions{}); err == nil {
+		t.Error("expected an error for a repeated source")
+	}
+}
+
+func TestMultiSourceTagsFocusOfEachSource(t *testing.T) {
+	multi, work, home := newTestMultiSource()
+	home.capabilities.FocusCount = 1
+	top := []task{
+		CreateTestTask("work/1", "Write report", ""),
+		CreateTestTask("home/1", "Buy milk", ""),
+	}
+
+	if multi.Capabilities().FocusCount != 1 {
+		t.Errorf("expected a focus count of 1, got %d", multi.Capabilities().FocusCount)
+	}
+	if err := multi.TagFocus(top); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(work.focused) != 0 {
+		t.Error("sources without a focus count should not be tagged")
+	}
+	// Only the first task of the merged ranking is in home's top 1.
+	if len(home.focused) != 1 || len(home.focused[0]) != 0 {
+		t.Errorf("expected home to tag none of its tasks, got %+v", home.focused)
+	}
+}
+
+func TestMultiSourceSetsStatusInTheTasksSource(t *testing.T) {
+	multi, work, home := newTestMultiSource()
+	home.capabilities.WriteStatuses = []string{StatusCompleted}
+	work.capabilities.WriteStatuses = []string{StatusCompleted, StatusCanceled}
+	if statuses := multi.Capabilities().WriteStatuses; len(statuses) != 2 {
+		t.Errorf("expected both statuses, got %v", statuses)
+	}
+
+	if err := multi.SetStatus("home/1", StatusCompleted); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(home.statuses) != 1 || home.statuses[0] != "1=completed" {
+		t.Errorf("expected home to complete its task 1, got %v", home.statuses)
+	}
+	if err := multi.SetStatus("home/1", StatusCanceled); err == nil || len(home.statuses) != 1 {
+		t.Error("expected an error for a status the source can't set")
+	}
+	work.capabilities.WriteStatuses = nil
+	if err := multi.SetStatus("work/1", StatusCompleted); err == nil || len(work.statuses) != 0 {
+		t.Error("expected an error for a source that can't set statuses")
+	}
+	if err := multi.SetStatus("other/1", StatusCompleted); err == nil {
+		t.Error("expected an error for an unknown source")
+	}
+}
+
+func TestMultiSourceCreatesTaskInFirstCapableSource(t *testing.T) {
+	multi, work, home := newTestMultiSource()
+	home.capabilities.CreateTasks = true
+
+	created, err := multi.CreateTask("Buy bread")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if created.ID != "home/new-Buy bread" || len(home.created) != 1 || len(work.created) != 0 {
+		t.Errorf("expected the task to be created in home, got %+v", created)
+	}
+
+	home.capabilities.CreateTasks = false
+	if _, err := multi.CreateTask("Buy bread"); err == nil {
+		t.Error("expected an error when no source can create tasks")
+	}
+}
+
+func TestMultiSourceRenamesTaskInItsSource(t *testing.T) {
+	multi, _, home := newTestMultiSource()
+	home.capabilities.RenameTasks = true
+	home.renamedID = "2"
+
+	newID, err := multi.RenameTask("home/1", "Buy oat milk")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if newID != "home/2" || len(home.renames) != 1 || home.renames[0] != "1=Buy oat milk" {
+		t.Errorf("expected home to rename its task 1, got %s and %v", newID, home.renames)
+	}
+	if _, err := multi.RenameTask("work/1", "Write report"); err == nil {
+		t.Error("expected an error for a source that can't rename tasks")
+	}
+}
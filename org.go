@@ -0,0 +1,200 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// orgKeywords maps the TODO keywords of Org-mode headlines to task statuses.
+// Headlines without one of the keywords aren't tasks.
+type orgKeywords map[string]string
+
+// newOrgKeywords builds the keyword mapping from comma-separated lists of
+// keywords for open, completed, and canceled tasks.
+func newOrgKeywords(open, done, canceled string) orgKeywords {
+	keywords := make(orgKeywords)
+	for status, list := range map[string]string{
+		StatusOpen:      open,
+		StatusCompleted: done,
+		StatusCanceled:  canceled,
+	} {
+		for _, keyword := range strings.Split(list, ",") {
+			if keyword = strings.TrimSpace(keyword); keyword != "" {
+				keywords[keyword] = status
+			}
+		}
+	}
+	return keywords
+}
+
+// orgSource reads tasks from the headlines of Org-mode files:
+//
+//	#+TITLE: Notes
+//	* Work
+//	** TODO Write report                                      :office:
+//	   DEADLINE: <2025-01-10 Fri>
+//	   :PROPERTIES:
+//	   :ID:       6b1e3c1a-0d2e-4d8b-9a55-3f0b1c2d4e5f
+//	   :END:
+//	** DONE Send invoice
+//
+// Every task gets the closest headline above it without a keyword as its
+// project.
+type orgSource struct {
+	paths    []string
+	keywords orgKeywords
+}
+
+func newOrgSource(opts sourceOptions) (TaskSource, error) {
+	if len(opts.orgFiles) == 0 {
+		return nil, errors.New("the org source needs at least one --org-file")
+	}
+	var paths []string
+	for _, path := range opts.orgFiles {
+		path, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	keywords := newOrgKeywords(opts.orgTodoKeywords, opts.orgDoneKeywords, opts.orgCanceledKeywords)
+	return orgSource{paths: paths, keywords: keywords}, nil
+}
+
+func (s orgSource) ID() string {
+	return "org:" + strings.Join(s.paths, ",")
+}
+
+func (s orgSource) Capabilities() sourceCapabilities {
+	return sourceCapabilities{Watch: s.paths}
+}
+
+func (s orgSource) Fetch() ([]task, error) {
+	var tasks []task
+	for _, path := range s.paths {
+		lines, err := readLines(path)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, parseOrgTasks(path, lines, s.keywords)...)
+	}
+	return tasks, nil
+}
+
+var (
+	orgHeadline  = regexp.MustCompile(`^(\*+)\s+(.*?)\s*$`)
+	orgTags      = regexp.MustCompile(`\s+:([^\s:]+(?::[^\s:]+)*):$`)
+	orgPriority  = regexp.MustCompile(`^\[#[A-Za-z0-9]\]\s*`)
+	orgPlanning  = regexp.MustCompile(`(SCHEDULED|DEADLINE|CLOSED):\s*[<\[](\d{4}-\d{2}-\d{2})(?:\s+[A-Za-z]+)?(?:\s+(\d{1,2}:\d{2}))?[^>\]]*[>\]]`)
+	orgProperty  = regexp.MustCompile(`^\s*:([^:\s]+):\s*(.*?)\s*$`)
+	orgDrawerEnd = regexp.MustCompile(`(?i)^\s*:END:\s*$`)
+)
+
+// parseOrgTasks returns the headlines with a TODO keyword in the lines of the
+// Org file at path.
+//
+// Headlines with an :ID: property use it as their ID, so they keep it even when
+// their title is edited. Other headlines get an ID derived from the file and
+// their title, which survives the keyword changing and lines moving around.
+func parseOrgTasks(path string, lines []string, keywords orgKeywords) []task {
+	var tasks []task
+	ids := newStableIDs()
+	// projects holds the titles of the enclosing headlines without a keyword,
+	// by level.
+	var projects []string
+	// current is the index of the task whose planning line and properties
+	// drawer can still follow, or -1.
+	current := -1
+	inProperties := false
+	for _, line := range lines {
+		if m := orgHeadline.FindStringSubmatch(line); m != nil {
+			current = -1
+			inProperties = false
+			level := len(m[1])
+			if len(projects) >= level {
+				projects = projects[:level-1]
+			}
+
+			title := m[2]
+			var tags []string
+			if t := orgTags.FindStringSubmatch(title); t != nil {
+				tags = strings.Split(t[1], ":")
+				title = title[:len(title)-len(t[0])]
+			}
+			keyword, rest, _ := strings.Cut(title, " ")
+			status, isTask := keywords[keyword]
+			if !isTask {
+				for len(projects) < level-1 {
+					projects = append(projects, "")
+				}
+				projects = append(projects, strings.TrimSpace(orgPriority.ReplaceAllString(title, "")))
+				continue
+			}
+			name := strings.TrimSpace(orgPriority.ReplaceAllString(strings.TrimSpace(rest), ""))
+			if name == "" {
+				continue
+			}
+			t := task{
+				ID:     ids.next(path + "\x00" + name),
+				Name:   name,
+				Status: status,
+				Tags:   tags,
+			}
+			for i := len(projects) - 1; i >= 0; i-- {
+				if projects[i] != "" {
+					t.Project = projects[i]
+					break
+				}
+			}
+			tasks = append(tasks, t)
+			current = len(tasks) - 1
+			continue
+		}
+
+		if current == -1 {
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case inProperties && orgDrawerEnd.MatchString(line):
+			inProperties = false
+			// Nothing but body text can follow the properties drawer.
+			current = -1
+		case inProperties:
+			if m := orgProperty.FindStringSubmatch(line); m != nil && strings.EqualFold(m[1], "ID") && m[2] != "" {
+				tasks[current].ID = path + "#" + m[2]
+			}
+		case strings.EqualFold(trimmed, ":PROPERTIES:"):
+			inProperties = true
+		case orgPlanning.MatchString(line):
+			for _, m := range orgPlanning.FindAllStringSubmatch(line, -1) {
+				at, err := parseOrgTimestamp(m[2], m[3])
+				if err != nil {
+					continue
+				}
+				switch m[1] {
+				case "SCHEDULED":
+					tasks[current].Scheduled = &at
+				case "DEADLINE":
+					tasks[current].Deadline = &at
+				}
+			}
+		default:
+			// The headline's body has started.
+			current = -1
+		}
+	}
+	return tasks
+}
+
+// parseOrgTimestamp parses the date and optional time of an Org timestamp in
+// local time.
+func parseOrgTimestamp(date, clock string) (time.Time, error) {
+	if clock == "" {
+		return time.ParseInLocation("2006-01-02", date, time.Local)
+	}
+	return time.ParseInLocation("2006-01-02 15:04", date+" "+clock, time.Local)
+}
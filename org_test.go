@@ -0,0 +1,112 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func testOrgKeywords() orgKeywords {
+	return newOrgKeywords("TODO,NEXT", "DONE", "CANCELLED")
+}
+
+func TestParseOrgTasks(t *testing.T) {
+	lines := []string{
+		"#+TITLE: Tasks",
+		"* Work",
+		"** TODO [#A] Write report :office:writing:",
+		"   DEADLINE: <2025-01-10 Fri> SCHEDULED: <2025-01-08 Wed 09:30>",
+		"   :PROPERTIES:",
+		"   :ID:       report-id",
+		"   :END:",
+		"   Some notes.",
+		"   SCHEDULED: <2025-02-01 Sat>",
+		"** DONE Send invoice",
+		"*** Notes about the invoice",
+		"**** NEXT Follow up",
+		"* Home",
+		"** CANCELLED Book flights",
+		"** Buy milk",
+		"** WAITING Not a configured keyword",
+	}
+
+	tasks := parseOrgTasks("/notes/tasks.org", lines, testOrgKeywords())
+
+	expected := []struct {
+		name    string
+		status  string
+		project string
+	}{
+		{"Write report", StatusOpen, "Work"},
+		{"Send invoice", StatusCompleted, "Work"},
+		{"Follow up", StatusOpen, "Notes about the invoice"},
+		{"Book flights", StatusCanceled, "Home"},
+	}
+	if len(tasks) != len(expected) {
+		t.Fatalf("expected %d tasks, got %d: %+v", len(expected), len(tasks), tasks)
+	}
+	for i, e := range expected {
+		if tasks[i].Name != e.name || tasks[i].Status != e.status || tasks[i].Project != e.project {
+			t.Errorf("task %d: expected %+v, got %+v", i, e, tasks[i])
+		}
+	}
+
+	report := tasks[0]
+	if report.ID != "/notes/tasks.org#report-id" {
+		t.Errorf("expected the ID property to be used, got %s", report.ID)
+	}
+	if strings.Join(report.Tags, ",") != "office,writing" {
+		t.Errorf("unexpected tags: %v", report.Tags)
+	}
+	if report.Deadline == nil || !report.Deadline.Equal(time.Date(2025, 1, 10, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("unexpected deadline: %v", report.Deadline)
+	}
+	// The SCHEDULED line in the body is ignored.
+	if report.Scheduled == nil || !report.Scheduled.Equal(time.Date(2025, 1, 8, 9, 30, 0, 0, time.Local)) {
+		t.Errorf("unexpected scheduled time: %v", report.Scheduled)
+	}
+}
+
+func TestOrgIDsSurviveKeywordChanges(t *testing.T) {
+	before := parseOrgTasks("/notes/tasks.org", []string{"* TODO Write report"}, testOrgKeywords())
+	after := parseOrgTasks("/notes/tasks.org", []string{"* Intro", "* DONE Write report"}, testOrgKeywords())
+
+	if before[0].ID != after[0].ID {
+		t.Error("changing the keyword of a task should not change its ID")
+	}
+}
+
+func TestNewOrgKeywords(t *testing.T) {
+	keywords := newOrgKeywords("TODO, NEXT", "DONE", "")
+	if keywords["NEXT"] != StatusOpen || keywords["DONE"] != StatusCompleted {
+		t.Errorf("unexpected keywords: %v", keywords)
+	}
+	if _, ok := keywords[""]; ok {
+		t.Error("empty keywords should be ignored")
+	}
+}
+
+func TestOrgSourceFetch(t *testing.T) {
+	path := writeTestFile(t, "tasks.org", "* TODO First\n* DONE Second\n")
+	source, err := newOrgSource(sourceOptions{
+		orgFiles:        []string{path},
+		orgTodoKeywords: "TODO",
+		orgDoneKeywords: "DONE",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tasks, err := source.Fetch()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tasks) != 2 || tasks[1].Status != StatusCompleted {
+		t.Errorf("unexpected tasks: %+v", tasks)
+	}
+}
+
+func TestNewOrgSourceRequiresFiles(t *testing.T) {
+	if _, err := newOrgSource(sourceOptions{}); err == nil {
+		t.Error("expected an error without Org files")
+	}
+}
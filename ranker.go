@@ -0,0 +1,339 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// Ranker decides which tasks are compared, and ranks the tasks by the
+// outcomes of the comparisons. Rankers keep the ranking in the parent pointers
+// of the tasks, where a task's parent is ranked above it. Those are stored
+// between runs, so every ranker can continue a stored ranking.
+type Ranker interface {
+	// NextPair returns the two open tasks to compare next, or nil tasks when
+	// every open task is ranked.
+	NextPair(tasks []task) (a, b *task)
+	// CanCompare reports whether a and b still need to be compared, e.g. after
+	// the tasks were fetched again.
+	CanCompare(tasks []task, a, b task) bool
+	// Record ranks the task with the ID winnerID above the task with the ID
+	// loserID, and returns the change it made to their parents. The compared
+	// tasks of the decision are left empty.
+	Record(tasks []task, winnerID, loserID string) decision
+	// Undo reverts a decision that Record returned.
+	Undo(tasks []task, d decision)
+	// Reset forgets every decision, so every task has to be ranked again.
+	Reset(tasks []task)
+	// ReplaceID updates what the ranker keeps besides the parents when the
+	// source gives the task with the ID id the ID newID, e.g. after a rename.
+	ReplaceID(id, newID string)
+	// Ordered returns the ranked tasks from the highest priority to the
+	// lowest, followed by the tasks that still need to be compared. Those are
+	// grouped by the best order known so far.
+	Ordered(tasks []task) (ranked []task, pending [][]task)
+	// Progress returns how many of the open tasks are ranked, and how many
+	// open tasks there are.
+	Progress(tasks []task) (ranked, total int)
+}
+
+// rankerConstructors maps the names accepted by --ranking to the functions that
+// build each ranker.
+var rankerConstructors = map[string]func() Ranker{
+	"binary": newBinaryRanker,
+	"tree":   newTreeRanker,
+}
+
+// defaultRanking is the ranker that is used without --ranking.
+const defaultRanking = "tree"
+
+// rankerNames returns the names accepted by --ranking in alphabetical order.
+func rankerNames() []string {
+	var names []string
+	for name := range rankerConstructors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newRanker builds the ranker registered under name.
+func newRanker(name string) (Ranker, error) {
+	constructor, ok := rankerConstructors[name]
+	if !ok {
+		return nil, fmt.Errorf(
+			"unknown ranking %q (available: %s)",
+			name,
+			strings.Join(rankerNames(), ", "),
+		)
+	}
+	return constructor(), nil
+}
+
+// treeRanker ranks tasks like a tournament. The open tasks form a tree, and
+// two tasks at the highest level with several tasks are compared. The loser
+// becomes a child of the winner, and moves down a level. A task is ranked
+// once it, and every task above it, is alone at its level.
+type treeRanker struct{}
+
+func newTreeRanker() Ranker {
+	return treeRanker{}
+}
+
+func (treeRanker) NextPair(tasks []task) (*task, *task) {
+	tasksByLevel := assignLevels(tasks)
+	i := getHighestLevelWithMultipleTasks(tasksByLevel)
+	if i == -1 {
+		// There are no levels with multiple tasks.
+		return nil, nil
+	}
+	highestLevel := tasksByLevel[i]
+	a := rand.Intn(len(highestLevel))
+	// Pick another task, so the tasks aren't the same.
+	b := rand.Intn(len(highestLevel) - 1)
+	if b >= a {
+		b++
+	}
+	return &highestLevel[a], &highestLevel[b]
+}
+
+func (treeRanker) CanCompare(tasks []task, a, b task) bool {
+	tasksByLevel := assignLevels(tasks)
+	i := getHighestLevelWithMultipleTasks(tasksByLevel)
+	if i == -1 || a.ID == b.ID {
+		return false
+	}
+	return getTaskByID(a.ID, tasksByLevel[i]) != nil && getTaskByID(b.ID, tasksByLevel[i]) != nil
+}
+
+func (treeRanker) Record(tasks []task, winnerID, loserID string) decision {
+	return setParent(tasks, loserID, winnerID)
+}
+
+func (treeRanker) Undo(tasks []task, d decision) {
+	restoreParent(tasks, d)
+}
+
+func (treeRanker) Reset(tasks []task) {
+	for i := range tasks {
+		tasks[i].ParentID = nil
+	}
+}
+
+func (treeRanker) ReplaceID(id, newID string) {
+	// Everything the tree knows is in the parents.
+}
+
+func (treeRanker) Ordered(tasks []task) ([]task, [][]task) {
+	var ranked []task
+	tasksByLevel := assignLevels(tasks)
+	for i, level := range tasksByLevel {
+		if len(level) != 1 {
+			// Tasks at this level and below still need to be compared.
+			return ranked, tasksByLevel[i:]
+		}
+		ranked = append(ranked, level[0])
+	}
+	return ranked, nil
+}
+
+func (r treeRanker) Progress(tasks []task) (int, int) {
+	ranked, pending := r.Ordered(tasks)
+	total := len(ranked)
+	for _, group := range pending {
+		total += len(group)
+	}
+	return len(ranked), total
+}
+
+// binaryRanker ranks like treeRanker, except that a task without children is
+// inserted into the longest chain of ranked tasks next to it by binary search,
+// instead of being compared with every task of the chain in turn. A task added
+// to 30 ranked tasks takes about 5 comparisons to place.
+type binaryRanker struct {
+	treeRanker
+	// above maps the IDs of the tasks being inserted to the ID of the task in
+	// the chain that they were last ranked above, which ends their search.
+	// Where the search starts is kept in their parent, like in the tree, so
+	// it continues after a restart, just with more comparisons.
+	above map[string]string
+}
+
+func newBinaryRanker() Ranker {
+	return &binaryRanker{above: make(map[string]string)}
+}
+
+func (r *binaryRanker) NextPair(tasks []task) (*task, *task) {
+	tasksByLevel := assignLevels(tasks)
+	i := getHighestLevelWithMultipleTasks(tasksByLevel)
+	if i == -1 {
+		return nil, nil
+	}
+	for _, newcomer := range tasksByLevel[i] {
+		chain := r.searchRange(tasks, newcomer)
+		if len(chain) == 0 {
+			continue
+		}
+		// Compare with the middle of the chain, on a random side like the
+		// tree does.
+		a, b := newcomer, chain[len(chain)/2]
+		if rand.Intn(2) == 0 {
+			a, b = b, a
+		}
+		return &a, &b
+	}
+	// No task can be inserted, e.g. when every task next to each other has
+	// children, so merge them like the tree does.
+	return r.treeRanker.NextPair(tasks)
+}
+
+func (r *binaryRanker) CanCompare(tasks []task, a, b task) bool {
+	if r.treeRanker.CanCompare(tasks, a, b) {
+		return true
+	}
+	return getTaskByID(b.ID, r.searchRange(tasks, a)) != nil ||
+		getTaskByID(a.ID, r.searchRange(tasks, b)) != nil
+}
+
+func (r *binaryRanker) Record(tasks []task, winnerID, loserID string) decision {
+	winner := getTaskByID(winnerID, tasks)
+	loser := getTaskByID(loserID, tasks)
+	if winner != nil && loser != nil && winner.getLevel(tasks) < loser.getLevel(tasks) {
+		// The task being inserted is ranked above a task further down the
+		// chain, so the search ends there. Its parent stays the same.
+		r.above[winnerID] = loserID
+		d := decision{childID: winnerID}
+		if winner.ParentID != nil {
+			d.previousParentID = *winner.ParentID
+		}
+		return d
+	}
+	// The winner gets a child, so it's no longer being inserted.
+	delete(r.above, winnerID)
+	d := r.treeRanker.Record(tasks, winnerID, loserID)
+	if below := getTaskByID(r.above[loserID], tasks); below != nil && below.ParentID != nil && *below.ParentID == winnerID {
+		// The loser was ranked above the next task of the chain, so it goes
+		// right between the winner and that task.
+		childID := d.childID
+		below.ParentID = &childID
+		d.belowID = below.ID
+		delete(r.above, loserID)
+	}
+	return d
+}
+
+func (r *binaryRanker) Undo(tasks []task, d decision) {
+	child := getTaskByID(d.childID, tasks)
+	if below := getTaskByID(d.belowID, tasks); below != nil && child != nil && child.ParentID != nil {
+		// The task below the child gets the child's parent back.
+		parentID := *child.ParentID
+		below.ParentID = &parentID
+	}
+	r.treeRanker.Undo(tasks, d)
+	// The end of the search may have been set by d, so search until the end
+	// of the chain again.
+	delete(r.above, d.childID)
+}
+
+func (r *binaryRanker) Reset(tasks []task) {
+	r.treeRanker.Reset(tasks)
+	// Searches that ended before the reset would be cut short.
+	clear(r.above)
+}
+
+func (r *binaryRanker) ReplaceID(id, newID string) {
+	if above, ok := r.above[id]; ok {
+		delete(r.above, id)
+		r.above[newID] = above
+	}
+	for newcomer, above := range r.above {
+		if above == id {
+			r.above[newcomer] = newID
+		}
+	}
+}
+
+// searchRange returns the tasks of the chain that newcomer is inserted into,
+// which it still needs to be compared with, from the highest priority to the
+// lowest. It returns nil if newcomer isn't inserted, because it has children
+// or there's no chain next to it.
+func (r *binaryRanker) searchRange(tasks []task, newcomer task) []task {
+	tasksByLevel := assignLevels(tasks)
+	i := getHighestLevelWithMultipleTasks(tasksByLevel)
+	if i == -1 || getTaskByID(newcomer.ID, tasksByLevel[i]) == nil {
+		return nil
+	}
+	children := openChildren(tasks)
+	if len(children[newcomer.ID]) > 0 {
+		// Its children would have to be inserted along with it.
+		return nil
+	}
+
+	// The tasks at the level all have the same parent, so newcomer fits
+	// anywhere in the chain below any of them.
+	var longest []task
+	for _, sibling := range tasksByLevel[i] {
+		if sibling.ID == newcomer.ID {
+			continue
+		}
+		chain := []task{sibling}
+		for len(children[chain[len(chain)-1].ID]) == 1 {
+			chain = append(chain, children[chain[len(chain)-1].ID][0])
+		}
+		if len(children[chain[len(chain)-1].ID]) > 1 {
+			// The tasks at the end of the chain aren't ranked yet.
+			continue
+		}
+		if len(chain) > len(longest) {
+			longest = chain
+		}
+	}
+	for j, t := range longest {
+		if t.ID == r.above[newcomer.ID] {
+			return longest[:j]
+		}
+	}
+	return longest
+}
+
+// openChildren maps the IDs of tasks to their children that are open and not
+// snoozed.
+func openChildren(tasks []task) map[string][]task {
+	children := make(map[string][]task)
+	for _, t := range tasks {
+		if t.ParentID != nil && t.getLevel(tasks) != -1 {
+			children[*t.ParentID] = append(children[*t.ParentID], t)
+		}
+	}
+	return children
+}
+
+// setParent makes the task with the ID parentID the parent of the task with the
+// ID childID, and returns the decision that reverts it.
+func setParent(tasks []task, childID, parentID string) decision {
+	d := decision{childID: childID}
+	child := getTaskByID(childID, tasks)
+	if child == nil {
+		return d
+	}
+	if child.ParentID != nil {
+		d.previousParentID = *child.ParentID
+	}
+	child.ParentID = &parentID
+	return d
+}
+
+// restoreParent gives the child of the decision its previous parent again.
+func restoreParent(tasks []task, d decision) {
+	child := getTaskByID(d.childID, tasks)
+	if child == nil {
+		return
+	}
+	if d.previousParentID == "" {
+		child.ParentID = nil
+	} else {
+		previousParentID := d.previousParentID
+		child.ParentID = &previousParentID
+	}
+}
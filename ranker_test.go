@@ -0,0 +1,287 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestNewRanker(t *testing.T) {
+	ranker, err := newRanker(defaultRanking)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := ranker.(treeRanker); !ok {
+		t.Errorf("expected the tree ranker by default, got %T", ranker)
+	}
+
+	_, err = newRanker("bogus")
+	if err == nil || !strings.Contains(err.Error(), "tree") {
+		t.Errorf("expected an error listing the rankings, got %v", err)
+	}
+}
+
+func TestTreeRankerRanksTasks(t *testing.T) {
+	ranker := newTreeRanker()
+	tasks := CreateTestTasks(3)
+
+	if ranked, total := ranker.Progress(tasks); ranked != 0 || total != 3 {
+		t.Errorf("expected 0 of 3 tasks ranked, got %d of %d", ranked, total)
+	}
+	for {
+		a, b := ranker.NextPair(tasks)
+		if a == nil || b == nil {
+			break
+		}
+		if a.ID == b.ID || !ranker.CanCompare(tasks, *a, *b) {
+			t.Fatalf("expected two different tasks to compare, got %s and %s", a.ID, b.ID)
+		}
+		// The task that comes first in the alphabet wins.
+		if a.ID < b.ID {
+			ranker.Record(tasks, a.ID, b.ID)
+		} else {
+			ranker.Record(tasks, b.ID, a.ID)
+		}
+	}
+
+	ranked, pending := ranker.Ordered(tasks)
+	if len(pending) != 0 || len(ranked) != 3 {
+		t.Fatalf("expected 3 ranked tasks, got %+v and %+v", ranked, pending)
+	}
+	for i, id := range []string{"a", "b", "c"} {
+		if ranked[i].ID != id {
+			t.Errorf("expected %s at position %d, got %s", id, i, ranked[i].ID)
+		}
+	}
+	if ranked, total := ranker.Progress(tasks); ranked != 3 || total != 3 {
+		t.Errorf("expected 3 of 3 tasks ranked, got %d of %d", ranked, total)
+	}
+}
+
+func TestTreeRankerUndo(t *testing.T) {
+	ranker := newTreeRanker()
+	tasks := CreateTestTasks(3)
+	tasks[1].ParentID = &tasks[0].ID
+
+	d := ranker.Record(tasks, "b", "c")
+	if d.childID != "c" || d.previousParentID != "" {
+		t.Errorf("expected the decision to move c from the root, got %+v", d)
+	}
+	d = ranker.Record(tasks, "a", "b")
+	if d.childID != "b" || d.previousParentID != "a" {
+		t.Errorf("expected the decision to keep the previous parent a, got %+v", d)
+	}
+
+	ranker.Undo(tasks, decision{childID: "c"})
+	if tasks[2].ParentID != nil {
+		t.Errorf("expected c to be a root task again, got parent %s", *tasks[2].ParentID)
+	}
+}
+
+func TestTreeRankerOrderedGroupsPendingTasks(t *testing.T) {
+	ranker := newTreeRanker()
+	tasks := CreateTestTasks(4)
+	tasks[1].ParentID = &tasks[0].ID
+	tasks[2].ParentID = &tasks[0].ID
+	tasks[3].ParentID = &tasks[1].ID
+
+	ranked, pending := ranker.Ordered(tasks)
+	if len(ranked) != 1 || ranked[0].ID != "a" {
+		t.Errorf("expected only a to be ranked, got %+v", ranked)
+	}
+	if len(pending) != 2 || len(pending[0]) != 2 || len(pending[1]) != 1 {
+		t.Errorf("expected b and c, then d, to be pending, got %+v", pending)
+	}
+	if ranker.CanCompare(tasks, tasks[0], tasks[1]) {
+		t.Error("expected a ranked task not to be compared")
+	}
+	if !ranker.CanCompare(tasks, tasks[1], tasks[2]) {
+		t.Error("expected b and c to be compared")
+	}
+}
+
+// rankWith compares the tasks with the ranker until every task is ranked. The
+// task that comes first in the alphabet wins. It returns the number of
+// comparisons.
+func rankWith(t *testing.T, ranker Ranker, tasks []task) int {
+	t.Helper()
+	comparisons := 0
+	for {
+		a, b := ranker.NextPair(tasks)
+		if a == nil || b == nil {
+			return comparisons
+		}
+		if a.ID == b.ID || !ranker.CanCompare(tasks, *a, *b) {
+			t.Fatalf("expected two different tasks to compare, got %s and %s", a.ID, b.ID)
+		}
+		comparisons++
+		if comparisons > 1000 {
+			t.Fatal("expected the ranking to end")
+		}
+		if a.Name < b.Name {
+			ranker.Record(tasks, a.ID, b.ID)
+		} else {
+			ranker.Record(tasks, b.ID, a.ID)
+		}
+	}
+}
+
+// assertRankedByName checks that every task is ranked in alphabetical order of
+// their names.
+func assertRankedByName(t *testing.T, ranker Ranker, tasks []task) {
+	t.Helper()
+	ranked, pending := ranker.Ordered(tasks)
+	if len(pending) != 0 || len(ranked) != len(tasks) {
+		t.Fatalf("expected %d ranked tasks, got %d and %d pending groups", len(tasks), len(ranked), len(pending))
+	}
+	for i := 1; i < len(ranked); i++ {
+		if ranked[i-1].Name > ranked[i].Name {
+			t.Errorf("expected %s above %s", ranked[i].Name, ranked[i-1].Name)
+		}
+	}
+}
+
+// createRankedChain returns count tasks named n00, n02, ... that are ranked in
+// that order.
+func createRankedChain(count int) []task {
+	tasks := make([]task, count)
+	for i := range tasks {
+		name := fmt.Sprintf("n%02d", 2*i)
+		tasks[i] = CreateTestTask(name, name, "")
+		if i > 0 {
+			tasks[i].ParentID = &tasks[i-1].ID
+		}
+	}
+	return tasks
+}
+
+func TestBinaryRankerInsertsNewTaskWithFewComparisons(t *testing.T) {
+	// Try every position in the chain, including the top and the bottom.
+	for position := 0; position <= 30; position++ {
+		tasks := createRankedChain(30)
+		name := fmt.Sprintf("n%02d", 2*position-1)
+		tasks = append(tasks, CreateTestTask("new", name, ""))
+
+		ranker := newBinaryRanker()
+		comparisons := rankWith(t, ranker, tasks)
+		if comparisons > 5 {
+			t.Errorf("position %d: expected at most 5 comparisons, got %d", position, comparisons)
+		}
+		assertRankedByName(t, ranker, tasks)
+
+		tasks = append(createRankedChain(30), CreateTestTask("new", name, ""))
+		if comparisons := rankWith(t, newTreeRanker(), tasks); position > 5 && comparisons <= 5 {
+			t.Errorf("position %d: expected the tree to need more comparisons, got %d", position, comparisons)
+		}
+	}
+}
+
+func TestBinaryRankerRanksFromScratch(t *testing.T) {
+	tasks := CreateTestTasks(12)
+	for i := range tasks {
+		// Shuffle the names, so the order of the tasks doesn't help.
+		tasks[i].Name = fmt.Sprintf("Task %02d", (i*5)%12)
+	}
+	ranker := newBinaryRanker()
+	rankWith(t, ranker, tasks)
+	assertRankedByName(t, ranker, tasks)
+}
+
+func TestBinaryRankerUndoRestartsSearch(t *testing.T) {
+	tasks := append(createRankedChain(8), CreateTestTask("new", "n01", ""))
+	ranker := newBinaryRanker()
+
+	a, b := ranker.NextPair(tasks)
+	newcomer, other := a, b
+	if b.ID == "new" {
+		newcomer, other = b, a
+	}
+	// The new task is ranked above the middle of the chain.
+	d := ranker.Record(tasks, newcomer.ID, other.ID)
+	if d.childID != "new" || getTaskByID("new", tasks).ParentID != nil {
+		t.Fatalf("expected only the search to change, got %+v", d)
+	}
+	if ranker.CanCompare(tasks, *newcomer, *other) {
+		t.Error("expected the search to end above the middle of the chain")
+	}
+
+	ranker.Undo(tasks, d)
+	if !ranker.CanCompare(tasks, *newcomer, *other) {
+		t.Error("expected the undone comparison to be comparable again")
+	}
+	rankWith(t, ranker, tasks)
+	assertRankedByName(t, ranker, tasks)
+}
+
+func TestBinaryRankerResetForgetsSearches(t *testing.T) {
+	tasks := append(createRankedChain(8), CreateTestTask("new", "n01", ""))
+	ranker := newBinaryRanker()
+	ranker.Record(tasks, "new", "n08")
+
+	ranker.Reset(tasks)
+	for _, task := range tasks {
+		if task.ParentID != nil {
+			t.Errorf("expected %s to be unranked, got parent %s", task.ID, *task.ParentID)
+		}
+	}
+	if ranked, _ := ranker.Progress(tasks); ranked != 0 {
+		t.Errorf("expected no ranked tasks, got %d", ranked)
+	}
+	// The chain is ranked again, so the new task is inserted into it. The
+	// search must cover the whole chain, not end above n08 like before.
+	tasks = append(createRankedChain(8), CreateTestTask("new", "n01", ""))
+	if !ranker.CanCompare(tasks, tasks[8], tasks[7]) {
+		t.Error("expected the search to reach the end of the chain again")
+	}
+	rankWith(t, ranker, tasks)
+	assertRankedByName(t, ranker, tasks)
+}
+
+func TestBinaryRankerReplaceIDKeepsSearches(t *testing.T) {
+	tasks := append(createRankedChain(8), CreateTestTask("new", "n01", ""))
+	ranker := newBinaryRanker()
+	ranker.Record(tasks, "new", "n08")
+
+	// Both the task being inserted and the end of its search are renamed.
+	getTaskByID("new", tasks).ID = "renamed"
+	ranker.ReplaceID("new", "renamed")
+	for i := range tasks {
+		if tasks[i].ID == "n08" {
+			tasks[i].ID = "n08b"
+		}
+		if tasks[i].ParentID != nil && *tasks[i].ParentID == "n08" {
+			newID := "n08b"
+			tasks[i].ParentID = &newID
+		}
+	}
+	ranker.ReplaceID("n08", "n08b")
+
+	newcomer := *getTaskByID("renamed", tasks)
+	if ranker.CanCompare(tasks, newcomer, *getTaskByID("n08b", tasks)) {
+		t.Error("expected the search to still end above the renamed task")
+	}
+	if !ranker.CanCompare(tasks, newcomer, *getTaskByID("n06", tasks)) {
+		t.Error("expected the search to still cover the tasks above it")
+	}
+}
+
+func TestBinaryRankerUndoInsertion(t *testing.T) {
+	tasks := append(createRankedChain(3), CreateTestTask("new", "n03", ""))
+	ranker := newBinaryRanker()
+
+	ranker.Record(tasks, "new", "n04")
+	ranker.Record(tasks, "n00", "new")
+	d := ranker.Record(tasks, "n02", "new")
+	if d.belowID != "n04" {
+		t.Fatalf("expected n04 to move below the new task, got %+v", d)
+	}
+	assertRankedByName(t, ranker, tasks)
+
+	ranker.Undo(tasks, d)
+	if parent := getTaskByID("n04", tasks).ParentID; parent == nil || *parent != "n02" {
+		t.Errorf("expected n04 to be below n02 again, got %v", parent)
+	}
+	if parent := getTaskByID("new", tasks).ParentID; parent == nil || *parent != "n00" {
+		t.Errorf("expected the new task to be below n00 again, got %v", parent)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// defaultCommandTimeout is how long a command may run before it is killed,
+// unless --command-timeout says otherwise.
+const defaultCommandTimeout = 10 * time.Second
+
+// commandWaitDelay is how long a killed command's output is waited for.
+const commandWaitDelay = 500 * time.Millisecond
+
+// commandRunner runs external commands, like osascript, for sources. Tests
+// substitute a fake runner so sources can be tested without the commands.
+type commandRunner interface {
+	// Run runs the command and returns what it printed to stdout.
+	Run(name string, args ...string) ([]byte, error)
+}
+
+// execRunner runs commands as child processes. Every command is killed when it
+// runs longer than timeout, or when ctx is canceled, e.g. because the program
+// is quitting.
+type execRunner struct {
+	ctx     context.Context
+	timeout time.Duration
+}
+
+func newExecRunner(ctx context.Context, timeout time.Duration) execRunner {
+	return execRunner{ctx: ctx, timeout: timeout}
+}
+
+func (r execRunner) Run(name string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(r.ctx, r.timeout)
+	defer cancel()
+
+	command := exec.CommandContext(ctx, name, args...)
+	killProcessGroup(command)
+	// Stop waiting for stdout if processes that the command started still
+	// hold it open after the command was killed.
+	command.WaitDelay = commandWaitDelay
+	var stderr bytes.Buffer
+	command.Stderr = &stderr
+	output, err := command.Output()
+	if err == nil {
+		return output, nil
+	}
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded) && r.ctx.Err() == nil:
+		return nil, fmt.Errorf("%s timed out after %s", name, r.timeout)
+	case ctx.Err() != nil:
+		return nil, fmt.Errorf("%s was canceled: %w", name, ctx.Err())
+	}
+	return nil, commandError{name: name, err: err, stderr: strings.TrimSpace(stderr.String())}
+}
+
+// commandError is returned when a command fails, with what it printed to
+// stderr to explain why.
+type commandError struct {
+	name   string
+	err    error
+	stderr string
+}
+
+func (e commandError) Error() string {
+	if e.stderr == "" {
+		return fmt.Sprintf("%s: %v", e.name, e.err)
+	}
+	return fmt.Sprintf("%s: %v: %s", e.name, e.err, e.stderr)
+}
+
+func (e commandError) Unwrap() error {
+	return e.err
+}
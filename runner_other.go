@@ -0,0 +1,10 @@
+//go:build !unix
+
+package main
+
+import "os/exec"
+
+// killProcessGroup does nothing where there are no process groups. Canceling
+// the command kills only its process, and WaitDelay stops waiting for the
+// processes it started.
+func killProcessGroup(command *exec.Cmd) {}
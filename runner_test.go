@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecRunnerReturnsStdout(t *testing.T) {
+	runner := newExecRunner(context.Background(), time.Second)
+	output, err := runner.Run("sh", "-c", "echo hello; echo ignored >&2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(output) != "hello\n" {
+		t.Errorf("expected hello, got %q", output)
+	}
+}
+
+func TestExecRunnerIncludesStderrInErrors(t *testing.T) {
+	runner := newExecRunner(context.Background(), time.Second)
+	_, err := runner.Run("sh", "-c", "echo 'Things3 is not running' >&2; exit 1")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if !strings.Contains(err.Error(), "Things3 is not running") {
+		t.Errorf("expected stderr in the error, got %v", err)
+	}
+}
+
+func TestExecRunnerKillsCommandsAfterTimeout(t *testing.T) {
+	runner := newExecRunner(context.Background(), 50*time.Millisecond)
+	start := time.Now()
+	_, err := runner.Run("sleep", "5")
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected a timeout error, got %v", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Error("command should have been killed")
+	}
+}
+
+func TestExecRunnerKillsCommandsWhenCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	runner := newExecRunner(ctx, time.Minute)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+
+	start := time.Now()
+	_, err := runner.Run("sleep", "5")
+	if err == nil || !strings.Contains(err.Error(), "canceled") {
+		t.Errorf("expected a cancellation error, got %v", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Error("command should have been killed")
+	}
+}
+
+func TestExecRunnerKillsPipedCommandsAfterTimeout(t *testing.T) {
+	runner := newExecRunner(context.Background(), 200*time.Millisecond)
+	start := time.Now()
+	// cat holds stdout open until sleep exits, even after sh was killed.
+	_, err := runner.Run("sh", "-c", "sleep 5 | cat")
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected a timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("command should have been killed, but it took %v", elapsed)
+	}
+}
@@ -0,0 +1,20 @@
+//go:build unix
+
+package main
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// killProcessGroup makes the command start its own process group, and kills
+// the whole group when the command is canceled. Commands like sh -c "a | b"
+// start grandchildren that would otherwise keep stdout open after sh is
+// killed.
+func killProcessGroup(command *exec.Cmd) {
+	command.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	command.Cancel = func() error {
+		// The process group has the same ID as its first process.
+		return syscall.Kill(-command.Process.Pid, syscall.SIGKILL)
+	}
+}
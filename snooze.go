@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// parseSnoozeUntil parses when a snooze ends, relative to now. It accepts
+// "tomorrow", durations like 30m, 2h, 3d, or 1w, a time like 15:04, which is
+// tomorrow if it already passed today, and dates like 2006-01-02 with an
+// optional time.
+func parseSnoozeUntil(input string, now time.Time) (time.Time, error) {
+	input = strings.ToLower(strings.TrimSpace(input))
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	if input == "tomorrow" {
+		return today.AddDate(0, 0, 1), nil
+	}
+	if n, err := strconv.Atoi(strings.TrimRight(input, "dw")); err == nil && n > 0 {
+		switch {
+		case strings.HasSuffix(input, "d"):
+			return now.AddDate(0, 0, n), nil
+		case strings.HasSuffix(input, "w"):
+			return now.AddDate(0, 0, 7*n), nil
+		}
+	}
+	if d, err := time.ParseDuration(input); err == nil && d > 0 {
+		return now.Add(d), nil
+	}
+	if t, err := time.ParseInLocation("15:04", input, now.Location()); err == nil {
+		at := today.Add(time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute)
+		if !at.After(now) {
+			at = at.AddDate(0, 0, 1)
+		}
+		return at, nil
+	}
+	for _, layout := range []string{time.DateOnly, "2006-01-02 15:04"} {
+		if at, err := time.ParseInLocation(layout, input, now.Location()); err == nil {
+			if !at.After(now) {
+				return time.Time{}, fmt.Errorf("%s has already passed", input)
+			}
+			return at, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("can't tell when %q is (try tomorrow, 2h, 3d, 15:00, or 2006-01-02)", input)
+}
+
+// openSnoozePrompt shows the prompt for when t wakes up.
+func (m *model) openSnoozePrompt(t *task) tea.Cmd {
+	if t == nil {
+		return nil
+	}
+	m.promptTaskID = t.ID
+	return m.openPrompt(promptSnooze, "Snooze until: ", "tomorrow, 2h, 3d, 15:00, or 2006-01-02", "")
+}
+
+// snoozeTask hides the task with the given ID until the entered time, and
+// returns the command that stores the snooze and wakes the task up.
+func (m *model) snoozeTask(id, input string) tea.Cmd {
+	until, err := parseSnoozeUntil(input, now())
+	if err != nil {
+		m.writeErr = fmt.Errorf("couldn't snooze: %w", err)
+		return nil
+	}
+	if getTaskByID(id, m.allTasks) == nil {
+		return nil
+	}
+	if m.snoozed == nil {
+		m.snoozed = make(map[string]time.Time)
+	}
+	m.snoozed[id] = until
+	m.applySnoozes()
+	return tea.Batch(
+		storeSnoozes(m.snoozed),
+		storeTasks(m.allTasks),
+		m.tagFocusIfChanged(),
+		wakeUpAt(until),
+	)
+}
+
+// wakeUpAt returns the command that checks for expired snoozes at the given
+// time.
+func wakeUpAt(until time.Time) tea.Cmd {
+	return tea.Tick(until.Sub(now()), func(time.Time) tea.Msg {
+		return snoozeExpiredMsg{}
+	})
+}
+
+// applySnoozes wakes up the tasks whose snooze expired and marks the others as
+// snoozed. Children of snoozed tasks move up to their first available
+// ancestor, like the children of closed tasks. It returns the command that
+// stores the snoozes if some expired, or nil otherwise.
+func (m *model) applySnoozes() tea.Cmd {
+	expired := false
+	for id, until := range m.snoozed {
+		if !now().Before(until) {
+			delete(m.snoozed, id)
+			expired = true
+		}
+	}
+	changed := false
+	for i := range m.allTasks {
+		_, snoozed := m.snoozed[m.allTasks[i].ID]
+		if m.allTasks[i].Snoozed != snoozed {
+			m.allTasks[i].Snoozed = snoozed
+			changed = true
+		}
+	}
+	if changed || len(m.snoozed) > 0 {
+		// Tasks may have been moved below snoozed ones since, e.g. by loading
+		// the stored relationships.
+		m.allTasks = syncTasks(m.allTasks, m.allTasks)
+		if m.comparisonTasksNeedUpdated() {
+			m.updateComparisonTasks()
+		}
+	}
+	if !expired {
+		return nil
+	}
+	return storeSnoozes(m.snoozed)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSnoozeUntil(t *testing.T) {
+	at := time.Date(2025, 3, 10, 14, 30, 0, 0, time.UTC)
+	tests := []struct {
+		input    string
+		expected time.Time
+	}{
+		{"tomorrow", time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC)},
+		{"2h", at.Add(2 * time.Hour)},
+		{"3d", at.AddDate(0, 0, 3)},
+		{"1w", at.AddDate(0, 0, 7)},
+		{"16:00", time.Date(2025, 3, 10, 16, 0, 0, 0, time.UTC)},
+		{"9:00", time.Date(2025, 3, 11, 9, 0, 0, 0, time.UTC)},
+		{"2025-04-01", time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{" 2025-04-01 08:15 ", time.Date(2025, 4, 1, 8, 15, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := parseSnoozeUntil(tt.input, at)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.input, err)
+			continue
+		}
+		if !got.Equal(tt.expected) {
+			t.Errorf("%q: expected %v, got %v", tt.input, tt.expected, got)
+		}
+	}
+
+	for _, input := range []string{"", "soon", "-2h", "0d", "2025-03-01"} {
+		if _, err := parseSnoozeUntil(input, at); err == nil {
+			t.Errorf("%q: expected an error", input)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// TaskSource is a backend that tasks are fetched from, e.g. Things.app.
+type TaskSource interface {
+	// ID identifies the source and its configuration. Priorities are stored
+	// separately for every ID, so two sources never share a state file.
+	ID() string
+	// Fetch returns the current list of tasks from the source. Returned tasks
+	// never have a ParentID.
+	Fetch() ([]task, error)
+	// Capabilities describes what the source supports.
+	Capabilities() sourceCapabilities
+}
+
+// sourceCapabilities describes how the program should interact with a source.
+type sourceCapabilities struct {
+	// Polling is true when the source has to be fetched on every refresh
+	// interval to pick up changes.
+	Polling bool
+	// Watch lists the files and directories that the source reads. Their
+	// changes trigger a fetch, so sources with them don't need polling.
+	Watch []string
+	// WritePriorities is true when the source implements priorityWriter and
+	// the user opted in to writing the ranking back to it.
+	WritePriorities bool
+	// WriteStatuses lists the statuses that tasks can be set to from sift, if
+	// the source implements statusWriter.
+	WriteStatuses []string
+	// CreateTasks is true when the source implements taskCreator, so tasks can
+	// be added from sift.
+	CreateTasks bool
+	// RenameTasks is true when the source implements taskRenamer, so tasks can
+	// be renamed from sift.
+	RenameTasks bool
+	// FocusCount is how many of the top tasks the source tags, if it
+	// implements focusTagger and the user opted in. It's 0 otherwise.
+	FocusCount int
+}
+
+// priorityWriter is implemented by sources that can store the ranking, so it
+// is visible outside of sift.
+type priorityWriter interface {
+	// WritePriorities stores the order of the given tasks, which are sorted
+	// from the highest priority to the lowest.
+	WritePriorities(ordered []task) error
+}
+
+// focusTagger is implemented by sources that can mark the top tasks of the
+// ranking, so they stand out outside of sift.
+type focusTagger interface {
+	// TagFocus tags the given tasks, which are the top of the ranking, and
+	// removes the tag from every other task.
+	TagFocus(top []task) error
+}
+
+// statusWriter is implemented by sources that can close tasks.
+type statusWriter interface {
+	// SetStatus sets the status of the task with the given ID to
+	// StatusCompleted or StatusCanceled.
+	SetStatus(id, status string) error
+}
+
+// taskCreator is implemented by sources that can create tasks.
+type taskCreator interface {
+	// CreateTask creates an open task with the given name and returns it.
+	CreateTask(name string) (task, error)
+}
+
+// taskRenamer is implemented by sources that can rename tasks.
+type taskRenamer interface {
+	// RenameTask changes the name of the task with the given ID, and returns
+	// the ID of the renamed task, which changes in sources that derive IDs
+	// from names.
+	RenameTask(id, name string) (string, error)
+}
+
+// sourceOptions holds the flag values that sources are configured with.
+type sourceOptions struct {
+	// runner runs the commands of sources. If it's nil, commands run without
+	// being canceled on quit, see getRunner.
+	runner commandRunner
+	// thingsList, thingsProject, thingsArea, and thingsTag select the to-dos
+	// of Things.app, see thingsSelection.
+	thingsList    string
+	thingsProject string
+	thingsArea    string
+	thingsTag     string
+	// thingsWritePriorities enables tagging the ranked to-dos of Things.app
+	// with their priority.
+	thingsWritePriorities bool
+	// thingsFocusTag is the tag that the top thingsFocusCount to-dos of
+	// Things.app are given, or empty to tag none.
+	thingsFocusTag   string
+	thingsFocusCount int
+	// todoFile is the path of the todo.txt file.
+	todoFile string
+	// todoWritePriorities enables writing the ranking to the todo.txt file.
+	todoWritePriorities bool
+	// markdownFiles are the paths of the Markdown files with checklists.
+	markdownFiles []string
+	// taskwarriorFile is the path of a file with the output of `task export`.
+	taskwarriorFile string
+	// taskwarriorCommand is run to export the tasks when there's no file.
+	taskwarriorCommand string
+	// icsPaths are .ics files or directories of them.
+	icsPaths []string
+	// orgFiles are the paths of the Org-mode files.
+	orgFiles []string
+	// orgTodoKeywords, orgDoneKeywords, and orgCanceledKeywords are
+	// comma-separated lists of the Org keywords for open, completed, and
+	// canceled tasks.
+	orgTodoKeywords     string
+	orgDoneKeywords     string
+	orgCanceledKeywords string
+	// command prints the tasks for the command source.
+	command string
+	// from is the file the json source reads, or "-" for stdin.
+	from string
+}
+
+// getRunner returns the runner that sources should run commands with.
+func (opts sourceOptions) getRunner() commandRunner {
+	if opts.runner == nil {
+		return newExecRunner(context.Background(), defaultCommandTimeout)
+	}
+	return opts.runner
+}
+
+// sourceConstructors maps the names accepted by --source to the functions that
+// build each source.
+var sourceConstructors = map[string]func(opts sourceOptions) (TaskSource, error){
+	"command":     newCommandSource,
+	"ics":         newICSSource,
+	"json":        newJSONSource,
+	"markdown":    newMarkdownSource,
+	"org":         newOrgSource,
+	"taskwarrior": newTaskwarriorSource,
+	"things":      newThingsSource,
+	"todotxt":     newTodoTxtSource,
+}
+
+// sourceNames returns the names accepted by --source in alphabetical order.
+func sourceNames() []string {
+	var names []string
+	for name := range sourceConstructors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newSource builds the source registered under name. A comma-separated list of
+// names builds a multiSource that merges all of them.
+func newSource(name string, opts sourceOptions) (TaskSource, error) {
+	if strings.Contains(name, ",") {
+		return newMultiSource(name, opts)
+	}
+	constructor, ok := sourceConstructors[name]
+	if !ok {
+		return nil, fmt.Errorf(
+			"unknown source %q (available: %s)",
+			name,
+			strings.Join(sourceNames(), ", "),
+		)
+	}
+	return constructor(opts)
+}
+
+// fetchTasks returns a command that fetches the tasks from the source. The
+// resulting message carries seq, so results of older fetches can be told apart.
+func fetchTasks(source TaskSource, seq int) tea.Cmd {
+	return func() tea.Msg {
+		Logger.Infof("Getting tasks from %s (fetch %d)", source.ID(), seq)
+		tasks, err := source.Fetch()
+		if err != nil {
+			return fetchErrorMsg{err: err, Seq: seq}
+		}
+		Logger.Debugf("Fetched tasks: %+v", tasks)
+		return tasksMsg{Tasks: tasks, Seq: seq, Fingerprint: fingerprintTasks(tasks)}
+	}
+}
+
+// writePriorities returns a command that writes the ranking to the source.
+func writePriorities(writer priorityWriter, ordered []task) tea.Cmd {
+	return func() tea.Msg {
+		Logger.Infof("Writing %d priorities to the source", len(ordered))
+		err := writer.WritePriorities(ordered)
+		if err != nil {
+			err = fmt.Errorf("couldn't write the priorities: %w", err)
+		}
+		return prioritiesWrittenMsg{err: err}
+	}
+}
+
+// setStatus returns a command that sets the status of the task with the given
+// ID in the source. previous holds the tasks that were changed in anticipation
+// of it, as they were before, so they can be restored if it fails.
+func setStatus(writer statusWriter, id, status string, previous []task) tea.Cmd {
+	return func() tea.Msg {
+		Logger.Infof("Setting the status of %s to %s", id, status)
+		err := writer.SetStatus(id, status)
+		if err != nil {
+			err = fmt.Errorf("couldn't mark the task %s: %w", status, err)
+		}
+		return statusWrittenMsg{err: err, previous: previous}
+	}
+}
+
+// createTask returns a command that creates a task with the given name in the
+// source.
+func createTask(creator taskCreator, name string) tea.Cmd {
+	return func() tea.Msg {
+		Logger.Infof("Creating the task %q", name)
+		created, err := creator.CreateTask(name)
+		if err != nil {
+			err = fmt.Errorf("couldn't add %q: %w", name, err)
+		}
+		return taskCreatedMsg{task: created, err: err}
+	}
+}
+
+// renameTask returns a command that renames the task with the given ID in the
+// source. previousName is restored if it fails.
+func renameTask(renamer taskRenamer, id, name, previousName string) tea.Cmd {
+	return func() tea.Msg {
+		Logger.Infof("Renaming %s to %q", id, name)
+		newID, err := renamer.RenameTask(id, name)
+		if err != nil {
+			err = fmt.Errorf("couldn't rename %q: %w", previousName, err)
+		}
+		return taskRenamedMsg{id: id, newID: newID, previousName: previousName, err: err}
+	}
+}
+
+// tagFocus returns a command that tags the top tasks in the source. ids are
+// the sorted IDs of the tasks, which the resulting message carries.
+func tagFocus(tagger focusTagger, top []task, ids []string) tea.Cmd {
+	return func() tea.Msg {
+		Logger.Infof("Tagging the top %d tasks in the source", len(top))
+		err := tagger.TagFocus(top)
+		if err != nil {
+			err = fmt.Errorf("couldn't tag the top tasks: %w", err)
+		}
+		return focusTaggedMsg{ids: ids, err: err}
+	}
+}
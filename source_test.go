@@ -0,0 +1,61 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestNewSourceReturnsErrorForUnknownSource(t *testing.T) {
+	_, err := newSource("nope", sourceOptions{})
+	if err == nil {
+		t.Error("expected an error for an unknown source")
+	}
+}
+
+func TestSourceNamesIncludesThings(t *testing.T) {
+	found := false
+	for _, name := range sourceNames() {
+		if name == "things" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected things in %v", sourceNames())
+	}
+}
+
+func TestStateFileNameKeepsTasksJSONForThings(t *testing.T) {
+	if got := stateFileName("things"); got != "tasks.json" {
+		t.Errorf("expected tasks.json, got %s", got)
+	}
+}
+
+func TestStateFileNameIsSafeForOtherSources(t *testing.T) {
+	if got := stateFileName("todotxt:/a b"); got != "tasks-todotxt__a_b.json" {
+		t.Errorf("expected tasks-todotxt__a_b.json, got %s", got)
+	}
+}
+
+func TestStateFileNameShortensLongIDs(t *testing.T) {
+	id := "markdown:/home/someone/notes/a-very-long-directory-name/work.md,/home/someone/notes/home.md"
+	name := stateFileName(id)
+	if len(name) > len("tasks-.json")+64 {
+		t.Errorf("state file name is too long: %s", name)
+	}
+	if name == stateFileName(id+"x") {
+		t.Error("different IDs should have different state files")
+	}
+}
+
+func TestStateFileNameShortensNonASCIIIDs(t *testing.T) {
+	// Every ü is two bytes, so cutting at byte 48 would split one.
+	id := "markdown:/home/" + strings.Repeat("ü", 60) + "/todo.md"
+	name := stateFileName(id)
+	if !utf8.ValidString(name) {
+		t.Errorf("state file name is not valid UTF-8: %q", name)
+	}
+	if len(name) > len("tasks-.json")+64 {
+		t.Errorf("state file name is too long: %s", name)
+	}
+}
@@ -1,13 +1,66 @@
 package main
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
 	"encoding/json"
+	"maps"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+// stateFile is the name of the file in the state directory that the
+// relationships between tasks are stored in. It depends on the task source, see
+// stateFileName.
+var stateFile = "tasks.json"
+
+// stateFileName returns the name of the file that relationships are stored in
+// for the source with the given ID. Things keeps the original tasks.json so
+// existing priorities survive upgrades.
+func stateFileName(sourceID string) string {
+	if sourceID == "things" {
+		return "tasks.json"
+	}
+	var b strings.Builder
+	for _, r := range sourceID {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	name := b.String()
+	if len(name) > 64 {
+		// Keep the name well within filesystem limits while staying unique.
+		// Cut at a rune boundary, so the name stays valid UTF-8.
+		cut := 48
+		for !utf8.RuneStart(name[cut]) {
+			cut--
+		}
+		sum := sha1.Sum([]byte(sourceID))
+		name = name[:cut] + "-" + hex.EncodeToString(sum[:])[:12]
+	}
+	return "tasks-" + name + ".json"
+}
+
+// cacheFileName returns the name of the file next to the given state file that
+// the last fetched tasks are cached in.
+func cacheFileName(stateFile string) string {
+	return strings.TrimSuffix(stateFile, ".json") + "-cache.json"
+}
+
+// snoozeFileName returns the name of the file next to the given state file that
+// the snoozed tasks are stored in.
+func snoozeFileName(stateFile string) string {
+	return strings.TrimSuffix(stateFile, ".json") + "-snoozed.json"
+}
+
 func getXDGStateDir() (string, error) {
 	if stateDir := os.Getenv("XDG_STATE_HOME"); stateDir != "" {
 		return stateDir, nil
@@ -47,7 +100,7 @@ func storeTasks(tasks []task) tea.Cmd {
 		}
 		Logger.Debugf("Created dir: %s", dir)
 
-		file := filepath.Join(dir, "tasks.json")
+		file := filepath.Join(dir, stateFile)
 		// Save tasks to a file.
 		err = os.WriteFile(file, json, 0o600)
 		if err != nil {
@@ -69,30 +122,148 @@ func loadRelationships(currentTasks []task) tea.Cmd {
 		}
 		Logger.Debugf("State dir: %s", stateDir)
 
+		storedRelationships, ok := readRelationships(stateDir)
+		if !ok {
+			// If the file doesn't exist or is invalid, return tasks as-is
+			return initialTasksMsg{Tasks: currentTasks}
+		}
+		applyRelationships(currentTasks, storedRelationships)
+		return initialTasksMsg{Tasks: currentTasks}
+	}
+}
+
+// readRelationships reads the stored relationships from the state directory.
+// It returns false if there are none or they can't be read.
+func readRelationships(stateDir string) (map[string]string, bool) {
+	file := filepath.Join(stateDir, "sift", stateFile)
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, false
+	}
+	Logger.Debugf("Read relationships from file: %s", file)
+	Logger.Debugf("Loaded json: %s", string(data))
+
+	var storedRelationships map[string]string
+	if err := json.Unmarshal(data, &storedRelationships); err != nil {
+		return nil, false
+	}
+	Logger.Debugf("Unmarshalled relationships: %+v", storedRelationships)
+	return storedRelationships, true
+}
+
+// applyRelationships sets the parents of the tasks to the stored ones.
+func applyRelationships(tasks []task, relationships map[string]string) {
+	for i := range tasks {
+		if parentID, ok := relationships[tasks[i].ID]; ok {
+			tasks[i].ParentID = &parentID
+		}
+	}
+}
+
+// storeCachedTasks saves the fetched tasks, so they can be shown right away
+// on the next start while the source is fetched.
+func storeCachedTasks(tasks []task) tea.Cmd {
+	return func() tea.Msg {
+		data, err := json.Marshal(tasks)
+		if err != nil {
+			return errorMsg{err}
+		}
+
+		stateDir, err := getXDGStateDir()
+		if err != nil {
+			return errorMsg{err}
+		}
 		dir := filepath.Join(stateDir, "sift")
-		file := filepath.Join(dir, "tasks.json")
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return errorMsg{err}
+		}
+
+		file := filepath.Join(dir, cacheFileName(stateFile))
+		if err := os.WriteFile(file, data, 0o600); err != nil {
+			return errorMsg{err}
+		}
+		Logger.Debugf("Cached %d tasks in file: %s", len(tasks), file)
+
+		return storageSuccessMsg{}
+	}
+}
+
+// loadCachedTasks loads the tasks of the last fetch with their stored
+// relationships. It returns no message if nothing is cached.
+func loadCachedTasks() tea.Cmd {
+	return func() tea.Msg {
+		stateDir, err := getXDGStateDir()
+		if err != nil {
+			return errorMsg{err}
+		}
+
+		file := filepath.Join(stateDir, "sift", cacheFileName(stateFile))
 		data, err := os.ReadFile(file)
 		if err != nil {
-			// If file doesn't exist, return tasks as-is
-			return initialTasksMsg{Tasks: currentTasks}
+			return nil
+		}
+		var tasks []task
+		if err := json.Unmarshal(data, &tasks); err != nil {
+			Logger.Errorf("Ignoring invalid cache %s: %v", file, err)
+			return nil
 		}
-		Logger.Debugf("Read relationships from file: %s", file)
-		Logger.Debugf("Loaded json: %s", string(data))
+		Logger.Debugf("Loaded %d cached tasks from file: %s", len(tasks), file)
 
-		var storedRelationships map[string]string
-		err = json.Unmarshal(data, &storedRelationships)
+		if relationships, ok := readRelationships(stateDir); ok {
+			applyRelationships(tasks, relationships)
+		}
+		return cachedTasksMsg{Tasks: tasks}
+	}
+}
+
+// storeSnoozes saves when the snoozed tasks wake up.
+func storeSnoozes(snoozed map[string]time.Time) tea.Cmd {
+	// Copy the snoozes, since the model keeps changing them.
+	snoozed = maps.Clone(snoozed)
+	return func() tea.Msg {
+		data, err := json.Marshal(snoozed)
 		if err != nil {
-			// If JSON is invalid, return tasks as-is
-			return initialTasksMsg{Tasks: currentTasks}
+			return errorMsg{err}
 		}
-		Logger.Debugf("Unmarshalled relationships: %+v", storedRelationships)
 
-		// Apply relationships to tasks
-		for i := range currentTasks {
-			if parentID, ok := storedRelationships[currentTasks[i].ID]; ok {
-				currentTasks[i].ParentID = &parentID
-			}
+		stateDir, err := getXDGStateDir()
+		if err != nil {
+			return errorMsg{err}
 		}
-		return initialTasksMsg{Tasks: currentTasks}
+		dir := filepath.Join(stateDir, "sift")
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return errorMsg{err}
+		}
+
+		file := filepath.Join(dir, snoozeFileName(stateFile))
+		if err := os.WriteFile(file, data, 0o600); err != nil {
+			return errorMsg{err}
+		}
+		Logger.Debugf("Stored %d snoozes in file: %s", len(snoozed), file)
+
+		return storageSuccessMsg{}
+	}
+}
+
+// loadSnoozes loads when the snoozed tasks wake up. It returns no message if
+// no task is snoozed.
+func loadSnoozes() tea.Cmd {
+	return func() tea.Msg {
+		stateDir, err := getXDGStateDir()
+		if err != nil {
+			return errorMsg{err}
+		}
+
+		file := filepath.Join(stateDir, "sift", snoozeFileName(stateFile))
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil
+		}
+		var snoozed map[string]time.Time
+		if err := json.Unmarshal(data, &snoozed); err != nil {
+			Logger.Errorf("Ignoring invalid snoozes %s: %v", file, err)
+			return nil
+		}
+		return snoozesMsg{Snoozed: snoozed}
 	}
 }
@@ -5,10 +5,11 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestStoreTasksWorksWithTasksWithNoParent(t *testing.T) {
-	tasks := getTasksFromThings().(tasksMsg).Tasks
+	tasks := CreateTestTasks(5)
 	cmd := storeTasks(tasks)
 	msg := cmd()
 	if _, ok := msg.(storageSuccessMsg); !ok {
@@ -17,7 +18,7 @@ func TestStoreTasksWorksWithTasksWithNoParent(t *testing.T) {
 }
 
 func TestStoreTasksWorksWithTasksWithParents(t *testing.T) {
-	tasks := getTasksFromThings().(tasksMsg).Tasks
+	tasks := CreateTestTasks(5)
 	taskParent := &tasks[0]
 	taskChild := &tasks[1]
 	taskChild.ParentID = &taskParent.ID
@@ -60,7 +61,7 @@ func TestGetXDGStateDirReturnsDefaultWhenEnvVarNotSet(t *testing.T) {
 }
 
 func TestLoadRelationshipsReturnsTasksWhenFileNotExists(t *testing.T) {
-	tasks := getTasksFromThings().(tasksMsg).Tasks
+	tasks := CreateTestTasks(5)
 	if len(tasks) == 0 {
 		t.Skip("No tasks to test")
 	}
@@ -88,7 +89,7 @@ func TestLoadRelationshipsReturnsTasksWhenFileNotExists(t *testing.T) {
 }
 
 func TestLoadRelationshipsHandlesCorruptedFile(t *testing.T) {
-	tasks := getTasksFromThings().(tasksMsg).Tasks
+	tasks := CreateTestTasks(5)
 	if len(tasks) == 0 {
 		t.Skip("No tasks to test")
 	}
@@ -393,3 +394,73 @@ func TestApplicationRecoveryFromMemoryConstraints(t *testing.T) {
 		}
 	}
 }
+
+func TestCacheFileNameIsNextToStateFile(t *testing.T) {
+	if name := cacheFileName("tasks.json"); name != "tasks-cache.json" {
+		t.Errorf("expected tasks-cache.json, got %s", name)
+	}
+	if name := cacheFileName("tasks-todotxt.json"); name != "tasks-todotxt-cache.json" {
+		t.Errorf("expected tasks-todotxt-cache.json, got %s", name)
+	}
+}
+
+func TestCachedTasksAreLoadedWithRelationships(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	tasks := CreateTestTasks(3)
+	if msg := storeCachedTasks(tasks)(); msg != (storageSuccessMsg{}) {
+		t.Fatalf("expected storageSuccessMsg, got %T: %v", msg, msg)
+	}
+	withParents := CreateTestTasks(3)
+	withParents[1].ParentID = &withParents[0].ID
+	if msg := storeTasks(withParents)(); msg != (storageSuccessMsg{}) {
+		t.Fatalf("expected storageSuccessMsg, got %T: %v", msg, msg)
+	}
+
+	msg, ok := loadCachedTasks()().(cachedTasksMsg)
+	if !ok {
+		t.Fatalf("expected cachedTasksMsg, got %T", msg)
+	}
+	if len(msg.Tasks) != 3 {
+		t.Fatalf("expected 3 cached tasks, got %d", len(msg.Tasks))
+	}
+	if parent := msg.Tasks[1].ParentID; parent == nil || *parent != "a" {
+		t.Errorf("expected the stored parent to be applied, got %v", parent)
+	}
+}
+
+func TestLoadCachedTasksWithoutCache(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	if msg := loadCachedTasks()(); msg != nil {
+		t.Errorf("expected no message without a cache, got %T", msg)
+	}
+
+	dir := filepath.Join(os.Getenv("XDG_STATE_HOME"), "sift")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, cacheFileName(stateFile)), []byte("not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if msg := loadCachedTasks()(); msg != nil {
+		t.Errorf("expected no message for an invalid cache, got %T", msg)
+	}
+}
+
+func TestSnoozesAreStoredAndLoaded(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	if msg := loadSnoozes()(); msg != nil {
+		t.Errorf("expected no message without snoozes, got %T", msg)
+	}
+
+	until := time.Date(2025, 3, 11, 9, 0, 0, 0, time.UTC)
+	if msg := storeSnoozes(map[string]time.Time{"a": until})(); msg != (storageSuccessMsg{}) {
+		t.Fatalf("expected storageSuccessMsg, got %T: %v", msg, msg)
+	}
+	msg, ok := loadSnoozes()().(snoozesMsg)
+	if !ok {
+		t.Fatalf("expected snoozesMsg, got %T", msg)
+	}
+	if len(msg.Snoozed) != 1 || !msg.Snoozed["a"].Equal(until) {
+		t.Errorf("expected a to be snoozed until %v, got %v", until, msg.Snoozed)
+	}
+}
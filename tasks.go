@@ -1,8 +1,9 @@
 package main
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
 	"encoding/json"
-	"os/exec"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -28,48 +29,42 @@ type task struct {
 	ID   string
 	Name string
 	// Can be StatusOpen, StatusCompleted, or StatusCanceled
-	Status   string
+	Status string
+	// Notes of the task, if it has any.
+	Notes string `json:",omitempty"`
+	// The project the task belongs to, if the source has projects.
+	Project string `json:",omitempty"`
+	// The area the task belongs to, if the source has areas.
+	Area string `json:",omitempty"`
+	// Tags, contexts, or categories of the task, if the source has any.
+	Tags []string `json:",omitempty"`
+	// When the task is scheduled to be worked on, if it is. In Things, this is
+	// the activation date.
+	Scheduled *time.Time `json:",omitempty"`
+	// When the task is due, if it has a due date.
+	Deadline *time.Time `json:",omitempty"`
 	ParentID *string
+	// Snoozed is true while the task is hidden until a later time. It's set by
+	// the model, never by sources.
+	Snoozed bool `json:"-"`
 }
 
-// A slice of slices of tasks, where each top-level slice represents a level in
-// the tree.
-type tasksByLevel [][]task
-
-func getTasksFromThings() tea.Msg {
-	Logger.Info("Getting Things tasks")
-	jxaScript := `
-	const Things = Application('Things3');
-	const todayList = Things.lists.byName('Today');
-	const todos = todayList.toDos();
-
-	let result = [];
-
-	todos.forEach(todo => {
-		const id = todo.id();
-		const name = todo.name();
-		const status = todo.status();
-
-		result.push({id, name, status});
-	});
-
-	JSON.stringify(result);
-	`
-	command := exec.Command("osascript", "-l", "JavaScript", "-e", jxaScript)
-	output, err := command.Output()
+// fingerprintTasks returns a hash of the fetched tasks, so that fetches that
+// return the same tasks as the previous one can be skipped.
+func fingerprintTasks(tasks []task) string {
+	data, err := json.Marshal(tasks)
 	if err != nil {
-		return errorMsg{err}
+		// Tasks always marshal, but an empty fingerprint is never skipped.
+		return ""
 	}
-	var tasks []task
-	err = json.Unmarshal(output, &tasks)
-	if err != nil {
-		return errorMsg{err}
-	}
-	Logger.Debugf("Marshaled todos: %+v", tasks)
-	Logger.Info("No errors fetching Things todos")
-	return tasksMsg{Tasks: tasks}
+	sum := sha1.Sum(data)
+	return hex.EncodeToString(sum[:])
 }
 
+// A slice of slices of tasks, where each top-level slice represents a level in
+// the tree.
+type tasksByLevel [][]task
+
 // findFirstAvailableAncestor walks up the ancestor chain starting from
 // parentID and returns the first ancestor ID that is not in the
 // unavailableParents map. Returns nil if no available ancestor is found.
@@ -118,6 +113,12 @@ func syncTasks(existingTasks []task, thingsTasks []task) []task {
 			// Task exists - update mutable fields but preserve parent relationship
 			existingTask.Name = t.Name
 			existingTask.Status = t.Status
+			existingTask.Notes = t.Notes
+			existingTask.Project = t.Project
+			existingTask.Area = t.Area
+			existingTask.Tags = t.Tags
+			existingTask.Scheduled = t.Scheduled
+			existingTask.Deadline = t.Deadline
 			mergedTasks = append(mergedTasks, existingTask)
 		} else {
 			// New task from Things - add as-is
@@ -148,10 +149,10 @@ func syncTasks(existingTasks []task, thingsTasks []task) []task {
 		}
 	}
 
-	// Add completed/canceled parents - these tasks exist but shouldn't have
-	// children
+	// Add completed/canceled and snoozed parents - these tasks exist but
+	// shouldn't have children
 	for _, task := range mergedTasks {
-		if task.Status == StatusCompleted || task.Status == StatusCanceled {
+		if task.Status == StatusCompleted || task.Status == StatusCanceled || task.Snoozed {
 			unavailableParents[task.ID] = task.ParentID
 		}
 	}
@@ -184,10 +185,10 @@ func getTaskByID(id string, tasks []task) *task {
 	return nil
 }
 
-// Gets the level of the task in the tree. Returns -1 if the task is completed
-// or canceled.
+// Gets the level of the task in the tree. Returns -1 if the task is completed,
+// canceled, or snoozed.
 func (t task) getLevel(tasks []task) int {
-	if t.Status == StatusCompleted || t.Status == StatusCanceled {
+	if t.Status == StatusCompleted || t.Status == StatusCanceled || t.Snoozed {
 		return -1
 	}
 	level := 0
@@ -212,7 +213,7 @@ func assignLevels(tasks []task) tasksByLevel {
 	for _, t := range tasks {
 		level := t.getLevel(tasks)
 		if level == -1 {
-			// Task is completed or canceled, so skip it.
+			// Task is completed, canceled, or snoozed, so skip it.
 			continue
 		}
 		for level >= len(tasksByLevel) {
@@ -247,6 +248,10 @@ func (t task) isFullyPrioritized(tasks []task) bool {
 	thisLevel := t.getLevel(tasks)
 	// If this task is the only task in the level, and if every level above it is
 	// the only task in its level, then this task if fully prioritized.
+	if thisLevel == -1 || thisLevel >= len(tasksByLevel) {
+		// The task was closed, or its parent was, so t is outdated.
+		return false
+	}
 
 	// If the tasks has siblings, then it is not fully prioritized.
 	if len(tasksByLevel[thisLevel]) != 1 {
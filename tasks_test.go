@@ -7,63 +7,6 @@ import (
 	"time"
 )
 
-// TestGetTodaysTasks verifies integration with Things.app
-// REQUIRES: Things.app to be running with at least one task in Today
-func TestGetTodaysTasks(t *testing.T) {
-	msg := getTasksFromThings()
-	switch msg := msg.(type) {
-	case tasksMsg:
-	case errorMsg:
-		t.Errorf("err should be nil, got %v", msg.err)
-	default:
-		t.Errorf("msg should be a tasksMsg or errorMsg, got %T", msg)
-	}
-}
-
-// TestThingsReturnsRealData verifies that Things.app returns actual data
-// REQUIRES: Things.app to be running with at least one task
-func TestThingsReturnsRealData(t *testing.T) {
-	tasks := getTasksFromThings().(tasksMsg).Tasks
-	if len(tasks) == 0 {
-		t.Error("tasks should not be empty")
-	}
-}
-
-// TestTaskFieldsAreNeverEmpty validates Things.app data structure
-// REQUIRES: Things.app to be running with at least one task
-func TestTaskFieldsAreNeverEmpty(t *testing.T) {
-	tasks := getTasksFromThings().(tasksMsg).Tasks
-	for _, task := range tasks {
-		if task.ID == "" || task.Name == "" || task.Status == "" {
-			t.Errorf("task fields should not be empty: %v", task)
-		}
-	}
-}
-
-// TestTasksFromThingsHaveNoParent validates Things returns tasks without parents
-// REQUIRES: Things.app to be running
-func TestTasksFromThingsHaveNoParent(t *testing.T) {
-	tasks := getTasksFromThings().(tasksMsg).Tasks
-	for i := range tasks {
-		if tasks[i].ParentID != nil {
-			t.Errorf("task should not have a parent: %v", tasks[i])
-		}
-	}
-}
-
-// TestTasksCanBeAssignedToParents tests Things data can be modified
-// REQUIRES: Things.app to be running with at least one task
-func TestTasksCanBeAssignedToParents(t *testing.T) {
-	tasks := getTasksFromThings().(tasksMsg).Tasks
-	id := "12345"
-	for _, task := range tasks {
-		task.ParentID = &id
-		if task.ParentID == nil {
-			t.Errorf("task should have a parent: %v", task)
-		}
-	}
-}
-
 func TestGetLevelReturnsZeroForTasksWithNoParent(t *testing.T) {
 	tasks := CreateTestTasks(1)
 	task := &tasks[0]
@@ -345,6 +288,18 @@ func TestIsFullyPrioritizedWithMultipleTasksAtLevel(t *testing.T) {
 	}
 }
 
+func TestIsFullyPrioritizedWithOutdatedTask(t *testing.T) {
+	tasks := CreateTestTasks(2)
+	tasks[1].Status = StatusCompleted
+
+	// Closed tasks, like a compared task that was completed since, have no
+	// level.
+	AssertTaskIsFullyPrioritized(t, tasks[1], tasks, false)
+	// A copy of a task that still points to its closed parent, which is
+	// deeper than any level.
+	AssertTaskIsFullyPrioritized(t, CreateTestTask("c", "Task C", "a"), tasks, false)
+}
+
 func TestIsFullyPrioritizedWithComplexHierarchy(t *testing.T) {
 	tasks := CreateTaskHierarchy(3, 2)
 
@@ -1962,3 +1917,61 @@ func TestSyncTasksHandlesMixedCompletedAndDeletedParents(t *testing.T) {
 		t.Error("Deleted parent task should not exist in results")
 	}
 }
+
+func TestSyncTasksUpdatesMetadata(t *testing.T) {
+	parentID := "a"
+	existingTasks := []task{
+		{ID: "a", Name: "Task A", Status: StatusOpen},
+		{ID: "b", Name: "Task B", Status: StatusOpen, Notes: "Old notes", ParentID: &parentID},
+	}
+	deadline := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+	thingsTasks := []task{
+		{ID: "a", Name: "Task A", Status: StatusOpen},
+		{
+			ID:       "b",
+			Name:     "Task B",
+			Status:   StatusOpen,
+			Notes:    "New notes",
+			Project:  "Launch",
+			Area:     "Work",
+			Tags:     []string{"Errand"},
+			Deadline: &deadline,
+		},
+	}
+
+	result := syncTasks(existingTasks, thingsTasks)
+
+	b := getTaskByID("b", result)
+	if b.Notes != "New notes" || b.Project != "Launch" || b.Area != "Work" || len(b.Tags) != 1 {
+		t.Errorf("metadata should be updated, got %+v", *b)
+	}
+	if b.Deadline == nil || !b.Deadline.Equal(deadline) {
+		t.Errorf("deadline should be updated, got %v", b.Deadline)
+	}
+	if b.ParentID == nil || *b.ParentID != "a" {
+		t.Error("parent should be preserved")
+	}
+}
+
+func TestFingerprintTasksChangesWithTasks(t *testing.T) {
+	tasks := CreateTestTasks(3)
+	fingerprint := fingerprintTasks(tasks)
+	if fingerprint == "" {
+		t.Fatal("expected a fingerprint")
+	}
+	if fingerprintTasks(CreateTestTasks(3)) != fingerprint {
+		t.Error("expected the same tasks to have the same fingerprint")
+	}
+
+	renamed := CreateTestTasks(3)
+	renamed[1].Name = "Renamed"
+	if fingerprintTasks(renamed) == fingerprint {
+		t.Error("expected a renamed task to change the fingerprint")
+	}
+
+	completed := CreateTestTasks(3)
+	completed[2].Status = StatusCompleted
+	if fingerprintTasks(completed) == fingerprint {
+		t.Error("expected a completed task to change the fingerprint")
+	}
+}
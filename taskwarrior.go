@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// taskwarriorSource reads the output of `task export`, either from a file or
+// by running a command.
+type taskwarriorSource struct {
+	// path is the file with the exported tasks. If it's empty, command is run
+	// instead.
+	path string
+	// command is run with sh -c and should print the exported tasks.
+	command string
+	runner  commandRunner
+}
+
+func newTaskwarriorSource(opts sourceOptions) (TaskSource, error) {
+	if opts.taskwarriorFile != "" {
+		path, err := filepath.Abs(opts.taskwarriorFile)
+		if err != nil {
+			return nil, err
+		}
+		return taskwarriorSource{path: path}, nil
+	}
+	command := opts.taskwarriorCommand
+	if command == "" {
+		command = "task export"
+	}
+	return taskwarriorSource{command: command, runner: opts.getRunner()}, nil
+}
+
+func (s taskwarriorSource) ID() string {
+	if s.path != "" {
+		return "taskwarrior:" + s.path
+	}
+	return "taskwarrior"
+}
+
+func (s taskwarriorSource) Capabilities() sourceCapabilities {
+	if s.path != "" {
+		return sourceCapabilities{Watch: []string{s.path}}
+	}
+	// There's no telling what the command reads, so it has to be polled.
+	return sourceCapabilities{Polling: true}
+}
+
+func (s taskwarriorSource) Fetch() ([]task, error) {
+	var output []byte
+	var err error
+	if s.path != "" {
+		output, err = os.ReadFile(s.path)
+	} else {
+		output, err = s.runner.Run("sh", "-c", s.command)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseTaskwarriorExport(output, time.Now())
+}
+
+// taskwarriorTask is a task as printed by `task export`.
+type taskwarriorTask struct {
+	UUID        string   `json:"uuid"`
+	Description string   `json:"description"`
+	Status      string   `json:"status"`
+	Project     string   `json:"project"`
+	Tags        []string `json:"tags"`
+	Due         string   `json:"due"`
+	End         string   `json:"end"`
+	Urgency     float64  `json:"urgency"`
+}
+
+// taskwarriorTimeLayout is the format of dates in `task export`.
+const taskwarriorTimeLayout = "20060102T150405Z"
+
+// parseTaskwarriorExport converts the output of `task export` to tasks, sorted
+// by urgency like `task next`.
+//
+// Completed and deleted tasks are only kept if they ended today, the way Things
+// keeps today's completed tasks in the Today list. Waiting and recurring
+// template tasks aren't actionable, so they're skipped.
+func parseTaskwarriorExport(data []byte, now time.Time) ([]task, error) {
+	var exported []taskwarriorTask
+	if err := json.Unmarshal(data, &exported); err != nil {
+		return nil, err
+	}
+	startOfToday := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	sort.SliceStable(exported, func(i, j int) bool {
+		return exported[i].Urgency > exported[j].Urgency
+	})
+
+	var tasks []task
+	for _, tw := range exported {
+		var status string
+		switch tw.Status {
+		case "pending":
+			status = StatusOpen
+		case "completed":
+			status = StatusCompleted
+		case "deleted":
+			status = StatusCanceled
+		default:
+			continue
+		}
+		if status != StatusOpen && tw.End != "" {
+			end, err := time.Parse(taskwarriorTimeLayout, tw.End)
+			if err == nil && end.Before(startOfToday) {
+				continue
+			}
+		}
+
+		t := task{
+			ID:      tw.UUID,
+			Name:    tw.Description,
+			Status:  status,
+			Project: tw.Project,
+			Tags:    tw.Tags,
+		}
+		if tw.Due != "" {
+			due, err := time.Parse(taskwarriorTimeLayout, tw.Due)
+			if err != nil {
+				return nil, err
+			}
+			due = due.Local()
+			t.Deadline = &due
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+const testTaskwarriorExport = `[
+{"id":1,"uuid":"a1","description":"Write report","status":"pending","project":"work","tags":["office"],"due":"20250110T170000Z","urgency":4.2},
+{"id":2,"uuid":"b2","description":"Buy milk","status":"pending","urgency":9.1},
+{"id":0,"uuid":"c3","description":"Send invoice","status":"completed","end":"20250105T090000Z","urgency":0},
+{"id":0,"uuid":"d4","description":"Old task","status":"completed","end":"20241201T090000Z","urgency":0},
+{"id":0,"uuid":"e5","description":"Book flights","status":"deleted","end":"20250105T100000Z","urgency":0},
+{"id":3,"uuid":"f6","description":"Later","status":"waiting","urgency":1},
+{"id":0,"uuid":"g7","description":"Water plants","status":"recurring","urgency":1}
+]`
+
+func TestParseTaskwarriorExport(t *testing.T) {
+	now := time.Date(2025, 1, 5, 12, 0, 0, 0, time.UTC)
+	tasks, err := parseTaskwarriorExport([]byte(testTaskwarriorExport), now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []struct {
+		id     string
+		status string
+	}{
+		// Sorted by urgency.
+		{"b2", StatusOpen},
+		{"a1", StatusOpen},
+		{"c3", StatusCompleted},
+		{"e5", StatusCanceled},
+	}
+	if len(tasks) != len(expected) {
+		t.Fatalf("expected %d tasks, got %d: %+v", len(expected), len(tasks), tasks)
+	}
+	for i, e := range expected {
+		if tasks[i].ID != e.id || tasks[i].Status != e.status {
+			t.Errorf("task %d: expected %s (%s), got %s (%s)", i, e.id, e.status, tasks[i].ID, tasks[i].Status)
+		}
+	}
+
+	report := tasks[1]
+	if report.Name != "Write report" || report.Project != "work" || len(report.Tags) != 1 || report.Tags[0] != "office" {
+		t.Errorf("unexpected fields: %+v", report)
+	}
+	if report.Deadline == nil || !report.Deadline.Equal(time.Date(2025, 1, 10, 17, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected deadline: %v", report.Deadline)
+	}
+}
+
+func TestParseTaskwarriorExportRejectsInvalidJSON(t *testing.T) {
+	if _, err := parseTaskwarriorExport([]byte("not json"), time.Now()); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+}
+
+func TestTaskwarriorSourceReadsFile(t *testing.T) {
+	path := writeTestFile(t, "export.json", `[{"uuid":"a1","description":"Write report","status":"pending"}]`)
+	source, err := newTaskwarriorSource(sourceOptions{taskwarriorFile: path})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tasks, err := source.Fetch()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].ID != "a1" {
+		t.Errorf("unexpected tasks: %+v", tasks)
+	}
+}
+
+func TestTaskwarriorSourceRunsCommand(t *testing.T) {
+	source, err := newTaskwarriorSource(sourceOptions{
+		taskwarriorCommand: `echo '[{"uuid":"a1","description":"Write report","status":"pending"}]'`,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tasks, err := source.Fetch()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].Name != "Write report" {
+		t.Errorf("unexpected tasks: %+v", tasks)
+	}
+}
@@ -1,6 +1,9 @@
 package main
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func CreateTestTask(id, name, parentID string) task {
 	var parent *string
@@ -82,3 +85,124 @@ func AssertModelHasNoComparisonTasks(t *testing.T, model model) {
 func CreateTestTasksMsg(count int) tasksMsg {
 	return tasksMsg{Tasks: CreateTestTasks(count)}
 }
+
+// fakeSource is a TaskSource that returns a fixed list of tasks, or err if it
+// is set, so the Update loop can be tested without Things.app.
+type fakeSource struct {
+	id           string
+	tasks        []task
+	err          error
+	capabilities sourceCapabilities
+	fetches      int
+	// written records every call to WritePriorities.
+	written [][]task
+	// focused records every call to TagFocus, which fails with focusErr if
+	// it is set.
+	focused  [][]task
+	focusErr error
+	// statuses records every call to SetStatus as "id=status", which fails
+	// with statusErr if it is set.
+	statuses  []string
+	statusErr error
+	// created records the names of the tasks created with CreateTask.
+	created []string
+	// renames records every call to RenameTask as "id=name". Renamed tasks
+	// get the ID renamedID if it is set.
+	renames   []string
+	renamedID string
+}
+
+func newFakeSource(tasks []task) *fakeSource {
+	return &fakeSource{
+		id:           "fake",
+		tasks:        tasks,
+		capabilities: sourceCapabilities{Polling: true},
+	}
+}
+
+func (s *fakeSource) ID() string {
+	return s.id
+}
+
+func (s *fakeSource) Capabilities() sourceCapabilities {
+	return s.capabilities
+}
+
+func (s *fakeSource) Fetch() ([]task, error) {
+	s.fetches++
+	if s.err != nil {
+		return nil, s.err
+	}
+	// Return a copy so the caller can't modify our tasks.
+	return append([]task(nil), s.tasks...), nil
+}
+
+func (s *fakeSource) WritePriorities(ordered []task) error {
+	s.written = append(s.written, ordered)
+	return s.err
+}
+
+func (s *fakeSource) TagFocus(top []task) error {
+	s.focused = append(s.focused, top)
+	if s.focusErr != nil {
+		return s.focusErr
+	}
+	return s.err
+}
+
+func (s *fakeSource) SetStatus(id, status string) error {
+	s.statuses = append(s.statuses, id+"="+status)
+	return s.statusErr
+}
+
+func (s *fakeSource) CreateTask(name string) (task, error) {
+	s.created = append(s.created, name)
+	if s.statusErr != nil {
+		return task{}, s.statusErr
+	}
+	return task{ID: "new-" + name, Name: name, Status: StatusOpen}, nil
+}
+
+func (s *fakeSource) RenameTask(id, name string) (string, error) {
+	s.renames = append(s.renames, id+"="+name)
+	if s.statusErr != nil {
+		return "", s.statusErr
+	}
+	if s.renamedID != "" {
+		return s.renamedID, nil
+	}
+	return id, nil
+}
+
+// CreateTestModel creates a model that fetches the given tasks from a
+// fakeSource.
+func CreateTestModel(tasks []task) model {
+	m := initialModel()
+	m.source = newFakeSource(tasks)
+	return m
+}
+
+// fakeRunner is a commandRunner that records the commands it is asked to run
+// instead of running them, and returns output, or err if it is set.
+type fakeRunner struct {
+	output string
+	err    error
+	// calls holds the name and arguments of every command.
+	calls [][]string
+}
+
+func (r *fakeRunner) Run(name string, args ...string) ([]byte, error) {
+	r.calls = append(r.calls, append([]string{name}, args...))
+	if r.err != nil {
+		return nil, r.err
+	}
+	return []byte(r.output), nil
+}
+
+// SetTestClock makes now return the given time until the test finishes.
+func SetTestClock(t *testing.T, at time.Time) {
+	t.Helper()
+	original := now
+	t.Cleanup(func() { now = original })
+	now = func() time.Time { return at }
+}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// thingsLists are the built-in lists of Things.app that can be prioritized.
+var thingsLists = []string{"Today", "Anytime", "Upcoming", "Someday", "Inbox"}
+
+// thingsCreatableLists are the lists that to-dos can be added to. Upcoming and
+// Someday need a date or a flag that sift doesn't set, so to-dos added there
+// would end up elsewhere.
+var thingsCreatableLists = []string{"Today", "Anytime", "Inbox"}
+
+// thingsSelection chooses which to-dos of Things.app are prioritized. Tasks
+// come from the project or area if one is set, or from the list otherwise, and
+// are narrowed down to the ones with the tag if it is set.
+type thingsSelection struct {
+	List    string `json:"list"`
+	Project string `json:"project,omitempty"`
+	Area    string `json:"area,omitempty"`
+	Tag     string `json:"tag,omitempty"`
+}
+
+// thingsPriorityTag matches the tags that WritePriorities gives to-dos, i.e.
+// sift:1, sift:2, and so on.
+const thingsPriorityTag = `^sift:\d+$`
+
+// thingsSource fetches to-dos from Things.app through osascript.
+type thingsSource struct {
+	selection thingsSelection
+	runner    commandRunner
+	// writePriorities is true when the user opted in to tagging the to-dos
+	// with their priority.
+	writePriorities bool
+	// focusTag is given to the top focusCount to-dos, unless it's empty.
+	focusTag   string
+	focusCount int
+}
+
+func newThingsSource(opts sourceOptions) (TaskSource, error) {
+	selection := thingsSelection{
+		List:    "Today",
+		Project: opts.thingsProject,
+		Area:    opts.thingsArea,
+		Tag:     opts.thingsTag,
+	}
+	if opts.thingsList != "" {
+		selection.List = ""
+		for _, list := range thingsLists {
+			if strings.EqualFold(list, opts.thingsList) {
+				selection.List = list
+			}
+		}
+		if selection.List == "" {
+			return nil, fmt.Errorf(
+				"unknown Things list %q (available: %s)",
+				opts.thingsList,
+				strings.Join(thingsLists, ", "),
+			)
+		}
+	}
+	if selection.Project != "" && selection.Area != "" {
+		return nil, errors.New("only one of --project and --area can be set")
+	}
+	if opts.thingsFocusTag != "" && opts.thingsFocusCount < 1 {
+		return nil, errors.New("--things-focus-count must be at least 1")
+	}
+	return thingsSource{
+		selection:       selection,
+		runner:          opts.getRunner(),
+		writePriorities: opts.thingsWritePriorities,
+		focusTag:        opts.thingsFocusTag,
+		focusCount:      opts.thingsFocusCount,
+	}, nil
+}
+
+// ID identifies the selection, so that every selection has its own
+// priorities. The Today list keeps the plain "things" ID it always had.
+func (s thingsSource) ID() string {
+	var parts []string
+	switch {
+	case s.selection.Project != "":
+		parts = append(parts, "project="+s.selection.Project)
+	case s.selection.Area != "":
+		parts = append(parts, "area="+s.selection.Area)
+	case s.selection.List != "Today" && s.selection.List != "":
+		parts = append(parts, "list="+s.selection.List)
+	}
+	if s.selection.Tag != "" {
+		parts = append(parts, "tag="+s.selection.Tag)
+	}
+	if len(parts) == 0 {
+		return "things"
+	}
+	return "things:" + strings.Join(parts, ",")
+}
+
+func (s thingsSource) Capabilities() sourceCapabilities {
+	// Things has no way to notify us of changes, so it has to be polled.
+	capabilities := sourceCapabilities{
+		Polling:         true,
+		WritePriorities: s.writePriorities,
+		WriteStatuses:   []string{StatusCompleted, StatusCanceled},
+		CreateTasks:     s.canCreateTasks(),
+		RenameTasks:     true,
+	}
+	if s.focusTag != "" {
+		capabilities.FocusCount = s.focusCount
+	}
+	return capabilities
+}
+
+// thingsContainerScript returns the part of a JXA script that puts the list,
+// project, or area of the selection into the variable container.
+func thingsContainerScript(selection thingsSelection) string {
+	// JSON is valid JavaScript, so this safely passes the selection to the
+	// script.
+	selectionJSON, _ := json.Marshal(selection)
+	return `
+	const Things = Application('Things3');
+	const selection = ` + string(selectionJSON) + `;
+
+	let container;
+	if (selection.project) {
+		container = Things.projects.byName(selection.project);
+	} else if (selection.area) {
+		container = Things.areas.byName(selection.area);
+	} else {
+		container = Things.lists.byName(selection.list);
+	}
+	`
+}
+
+// thingsSelectScript returns the part of a JXA script that selects the to-dos
+// into the variable todos.
+func thingsSelectScript(selection thingsSelection) string {
+	return thingsContainerScript(selection) + `
+	let todos = container.toDos();
+	if (selection.tag) {
+		todos = todos.filter(todo =>
+			todo.tagNames().split(', ').includes(selection.tag));
+	}
+	`
+}
+
+// thingsFetchScript returns the JXA script that prints the selected to-dos as
+// JSON.
+func thingsFetchScript(selection thingsSelection) string {
+	return thingsSelectScript(selection) + `
+
+	// Properties like the project throw or return null when they aren't set.
+	const nameOf = get => {
+		try {
+			const container = get();
+			return container ? container.name() : '';
+		} catch (e) {
+			return '';
+		}
+	};
+
+	let result = [];
+
+	todos.forEach(todo => {
+		const id = todo.id();
+		const name = todo.name();
+		const status = todo.status();
+		const notes = todo.notes();
+		const tags = todo.tagNames().split(', ').filter(tag => tag !== '');
+		const project = nameOf(() => todo.project());
+		// To-dos in a project belong to the project's area.
+		const area = nameOf(() => todo.area()) ||
+			nameOf(() => todo.project().area());
+		const scheduled = todo.activationDate();
+		const deadline = todo.dueDate();
+
+		result.push({
+			id, name, status, notes, tags, project, area, scheduled, deadline,
+		});
+	});
+
+	JSON.stringify(result);
+	`
+}
+
+func (s thingsSource) Fetch() ([]task, error) {
+	jxaScript := thingsFetchScript(s.selection)
+	output, err := s.runner.Run("osascript", "-l", "JavaScript", "-e", jxaScript)
+	if err != nil {
+		return nil, err
+	}
+	var tasks []task
+	err = json.Unmarshal(output, &tasks)
+	if err != nil {
+		return nil, err
+	}
+	Logger.Debugf("Marshaled todos: %+v", tasks)
+	Logger.Info("No errors fetching Things todos")
+	return tasks, nil
+}
+
+// thingsTagScript returns the JXA script that removes the tags matching the
+// managed regular expression from the selected to-dos, and then gives the
+// to-dos in tags the tag for their ID. Tags that don't exist yet are created.
+func thingsTagScript(selection thingsSelection, managed string, tags map[string]string) string {
+	managedJSON, _ := json.Marshal(managed)
+	tagsJSON, _ := json.Marshal(tags)
+	return thingsSelectScript(selection) + `
+	const managed = new RegExp(` + string(managedJSON) + `);
+	const tags = ` + string(tagsJSON) + `;
+
+	const existing = new Set(Things.tags.name());
+	Object.values(tags).forEach(tag => {
+		if (!existing.has(tag)) {
+			Things.make({new: 'tag', withProperties: {name: tag}});
+			existing.add(tag);
+		}
+	});
+
+	todos.forEach(todo => {
+		const current = todo.tagNames().split(', ').filter(tag => tag !== '');
+		const updated = current.filter(tag => !managed.test(tag));
+		const tag = tags[todo.id()];
+		if (tag) {
+			updated.push(tag);
+		}
+		if (updated.join(', ') !== current.join(', ')) {
+			todo.tagNames = updated.join(', ');
+		}
+	});
+	`
+}
+
+// WritePriorities tags the ordered to-dos sift:1, sift:2, and so on, and
+// removes these tags from every other to-do of the selection. Things
+// can't reorder its lists through scripting, so the tags make the ranking
+// visible instead.
+func (s thingsSource) WritePriorities(ordered []task) error {
+	tags := make(map[string]string)
+	for i, t := range ordered {
+		tags[t.ID] = fmt.Sprintf("sift:%d", i+1)
+	}
+	script := thingsTagScript(s.selection, thingsPriorityTag, tags)
+	_, err := s.runner.Run("osascript", "-l", "JavaScript", "-e", script)
+	return err
+}
+
+// TagFocus gives the top to-dos the focus tag and removes it from every other
+// to-do of the selection, including the ones that were completed.
+func (s thingsSource) TagFocus(top []task) error {
+	tags := make(map[string]string)
+	for _, t := range top {
+		tags[t.ID] = s.focusTag
+	}
+	script := thingsTagScript(s.selection, "^"+regexp.QuoteMeta(s.focusTag)+"$", tags)
+	_, err := s.runner.Run("osascript", "-l", "JavaScript", "-e", script)
+	return err
+}
+
+// SetStatus completes or cancels the to-do with the given ID.
+func (s thingsSource) SetStatus(id, status string) error {
+	idJSON, _ := json.Marshal(id)
+	statusJSON, _ := json.Marshal(status)
+	script := `
+	const Things = Application('Things3');
+	Things.toDos.byId(` + string(idJSON) + `).status = ` + string(statusJSON) + `;
+	`
+	_, err := s.runner.Run("osascript", "-l", "JavaScript", "-e", script)
+	return err
+}
+
+// canCreateTasks reports whether to-dos added to the container of the
+// selection show up in it.
+func (s thingsSource) canCreateTasks() bool {
+	if s.selection.Project != "" || s.selection.Area != "" {
+		return true
+	}
+	return slices.Contains(thingsCreatableLists, s.selection.List)
+}
+
+// CreateTask creates a to-do in the list, project, or area of the selection,
+// with the tag of the selection, so it's fetched with the others.
+func (s thingsSource) CreateTask(name string) (task, error) {
+	if !s.canCreateTasks() {
+		return task{}, fmt.Errorf("to-dos can't be added to the %s list", s.selection.List)
+	}
+	nameJSON, _ := json.Marshal(name)
+	script := thingsContainerScript(s.selection) + `
+	const properties = {name: ` + string(nameJSON) + `};
+	if (selection.tag) {
+		properties.tagNames = selection.tag;
+	}
+	const todo = Things.ToDo(properties);
+	container.toDos.push(todo);
+
+	JSON.stringify({id: todo.id(), name: todo.name(), status: todo.status()});
+	`
+	output, err := s.runner.Run("osascript", "-l", "JavaScript", "-e", script)
+	if err != nil {
+		return task{}, err
+	}
+	var created task
+	if err := json.Unmarshal(output, &created); err != nil {
+		return task{}, err
+	}
+	return created, nil
+}
+
+// RenameTask renames the to-do with the given ID, which keeps its ID.
+func (s thingsSource) RenameTask(id, name string) (string, error) {
+	idJSON, _ := json.Marshal(id)
+	nameJSON, _ := json.Marshal(name)
+	script := `
+	const Things = Application('Things3');
+	Things.toDos.byId(` + string(idJSON) + `).name = ` + string(nameJSON) + `;
+	`
+	if _, err := s.runner.Run("osascript", "-l", "JavaScript", "-e", script); err != nil {
+		return "", err
+	}
+	return id, nil
+}
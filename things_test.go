@@ -0,0 +1,398 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+// requireThings skips the test unless osascript is available to talk to
+// Things.app.
+func requireThings(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("osascript"); err != nil {
+		t.Skip("osascript is not available")
+	}
+}
+
+func getTasksFromThings(t *testing.T) []task {
+	t.Helper()
+	requireThings(t)
+	source, err := newThingsSource(sourceOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tasks, err := source.Fetch()
+	if err != nil {
+		t.Fatalf("err should be nil, got %v", err)
+	}
+	return tasks
+}
+
+func TestThingsSourceCapabilities(t *testing.T) {
+	source, err := newSource("things", sourceOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if source.ID() != "things" {
+		t.Errorf("expected ID things, got %s", source.ID())
+	}
+	if !source.Capabilities().Polling {
+		t.Error("Things should be polled")
+	}
+}
+
+// TestGetTodaysTasks verifies integration with Things.app
+// REQUIRES: Things.app to be running with at least one task in Today
+func TestGetTodaysTasks(t *testing.T) {
+	requireThings(t)
+	source, err := newThingsSource(sourceOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	msg := fetchTasks(source, 0)()
+	switch msg := msg.(type) {
+	case tasksMsg:
+	case fetchErrorMsg:
+		t.Errorf("err should be nil, got %v", msg.err)
+	default:
+		t.Errorf("msg should be a tasksMsg or fetchErrorMsg, got %T", msg)
+	}
+}
+
+// TestThingsReturnsRealData verifies that Things.app returns actual data
+// REQUIRES: Things.app to be running with at least one task
+func TestThingsReturnsRealData(t *testing.T) {
+	tasks := getTasksFromThings(t)
+	if len(tasks) == 0 {
+		t.Error("tasks should not be empty")
+	}
+}
+
+// TestTaskFieldsAreNeverEmpty validates Things.app data structure
+// REQUIRES: Things.app to be running with at least one task
+func TestTaskFieldsAreNeverEmpty(t *testing.T) {
+	tasks := getTasksFromThings(t)
+	for _, task := range tasks {
+		if task.ID == "" || task.Name == "" || task.Status == "" {
+			t.Errorf("task fields should not be empty: %v", task)
+		}
+	}
+}
+
+// TestTasksFromThingsHaveNoParent validates Things returns tasks without parents
+// REQUIRES: Things.app to be running
+func TestTasksFromThingsHaveNoParent(t *testing.T) {
+	tasks := getTasksFromThings(t)
+	for i := range tasks {
+		if tasks[i].ParentID != nil {
+			t.Errorf("task should not have a parent: %v", tasks[i])
+		}
+	}
+}
+
+// TestTasksCanBeAssignedToParents tests Things data can be modified
+// REQUIRES: Things.app to be running with at least one task
+func TestTasksCanBeAssignedToParents(t *testing.T) {
+	tasks := getTasksFromThings(t)
+	id := "12345"
+	for _, task := range tasks {
+		task.ParentID = &id
+		if task.ParentID == nil {
+			t.Errorf("task should have a parent: %v", task)
+		}
+	}
+}
+
+func TestThingsSourceIDsAreSeparatePerSelection(t *testing.T) {
+	tests := []struct {
+		opts     sourceOptions
+		expected string
+	}{
+		{sourceOptions{}, "things"},
+		{sourceOptions{thingsList: "today"}, "things"},
+		{sourceOptions{thingsList: "anytime"}, "things:list=Anytime"},
+		{sourceOptions{thingsProject: "Launch"}, "things:project=Launch"},
+		{sourceOptions{thingsArea: "Work", thingsTag: "Errand"}, "things:area=Work,tag=Errand"},
+		{sourceOptions{thingsTag: "Errand"}, "things:tag=Errand"},
+	}
+	for _, tt := range tests {
+		source, err := newThingsSource(tt.opts)
+		if err != nil {
+			t.Fatalf("unexpected error for %+v: %v", tt.opts, err)
+		}
+		if source.ID() != tt.expected {
+			t.Errorf("expected ID %s for %+v, got %s", tt.expected, tt.opts, source.ID())
+		}
+	}
+}
+
+func TestNewThingsSourceRejectsInvalidSelections(t *testing.T) {
+	if _, err := newThingsSource(sourceOptions{thingsList: "Logbook"}); err == nil {
+		t.Error("expected an error for an unknown list")
+	}
+	if _, err := newThingsSource(sourceOptions{thingsProject: "Launch", thingsArea: "Work"}); err == nil {
+		t.Error("expected an error when both a project and an area are set")
+	}
+}
+
+func TestThingsFetchScriptEscapesSelection(t *testing.T) {
+	script := thingsFetchScript(thingsSelection{List: "Today", Project: `It's "quoted"`})
+	if !strings.Contains(script, `"project":"It's \"quoted\""`) {
+		t.Errorf("expected the selection to be embedded as JSON, got:\n%s", script)
+	}
+}
+
+func TestThingsOutputUnmarshalsMetadata(t *testing.T) {
+	// The shape of the output of thingsFetchScript.
+	output := `[{
+		"id": "1",
+		"name": "Write report",
+		"status": "open",
+		"notes": "Include the numbers",
+		"tags": ["Work", "Writing"],
+		"project": "Launch",
+		"area": "Work",
+		"scheduled": "2025-01-08T08:00:00.000Z",
+		"deadline": null
+	}]`
+	var tasks []task
+	if err := json.Unmarshal([]byte(output), &tasks); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := tasks[0]
+	if got.Notes != "Include the numbers" || got.Project != "Launch" || got.Area != "Work" || len(got.Tags) != 2 {
+		t.Errorf("unexpected task: %+v", got)
+	}
+	if got.Scheduled == nil || !got.Scheduled.Equal(time.Date(2025, 1, 8, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected scheduled date: %v", got.Scheduled)
+	}
+	if got.Deadline != nil {
+		t.Errorf("expected no deadline, got %v", got.Deadline)
+	}
+}
+
+func TestThingsSourceFetchesThroughRunner(t *testing.T) {
+	runner := &fakeRunner{output: `[{"id": "1", "name": "Write report", "status": "open", "tags": ["Work"]}]`}
+	source, err := newThingsSource(sourceOptions{runner: runner, thingsList: "Anytime"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tasks, err := source.Fetch()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].Name != "Write report" || tasks[0].Tags[0] != "Work" {
+		t.Errorf("unexpected tasks: %+v", tasks)
+	}
+
+	if len(runner.calls) != 1 {
+		t.Fatalf("expected one command, got %d", len(runner.calls))
+	}
+	call := runner.calls[0]
+	if call[0] != "osascript" || call[1] != "-l" || call[2] != "JavaScript" {
+		t.Errorf("expected osascript with JavaScript, got %v", call[:3])
+	}
+	if !strings.Contains(call[len(call)-1], `"list":"Anytime"`) {
+		t.Error("expected the script to select the Anytime list")
+	}
+}
+
+func TestThingsSourceReturnsRunnerErrors(t *testing.T) {
+	runner := &fakeRunner{err: errors.New("Things3 got an error: Application isn't running")}
+	source, _ := newThingsSource(sourceOptions{runner: runner})
+
+	if _, err := source.Fetch(); err == nil {
+		t.Error("expected the runner's error")
+	}
+}
+
+func TestThingsSourceReturnsErrorForInvalidOutput(t *testing.T) {
+	runner := &fakeRunner{output: "not json"}
+	source, _ := newThingsSource(sourceOptions{runner: runner})
+
+	if _, err := source.Fetch(); err == nil {
+		t.Error("expected an error for invalid output")
+	}
+}
+
+func TestThingsSourceWritesPriorityTags(t *testing.T) {
+	runner := &fakeRunner{}
+	source, err := newThingsSource(sourceOptions{runner: runner, thingsTag: "Work", thingsWritePriorities: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !source.Capabilities().WritePriorities {
+		t.Fatal("expected writing priorities to be enabled")
+	}
+	writer, ok := source.(priorityWriter)
+	if !ok {
+		t.Fatal("expected Things to be a priorityWriter")
+	}
+
+	if err := writer.WritePriorities(CreateTestTasks(2)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(runner.calls) != 1 || runner.calls[0][0] != "osascript" {
+		t.Fatalf("expected one osascript command, got %v", runner.calls)
+	}
+	script := runner.calls[0][len(runner.calls[0])-1]
+	for _, expected := range []string{
+		`{"a":"sift:1","b":"sift:2"}`,
+		`new RegExp("^sift:\\d+$")`,
+		`"tag":"Work"`,
+	} {
+		if !strings.Contains(script, expected) {
+			t.Errorf("expected %s in the script:\n%s", expected, script)
+		}
+	}
+}
+
+func TestThingsSourceDoesNotWritePrioritiesByDefault(t *testing.T) {
+	source, _ := newThingsSource(sourceOptions{runner: &fakeRunner{}})
+	if source.Capabilities().WritePriorities {
+		t.Error("writing priorities should be opt-in")
+	}
+}
+
+func TestThingsSourceReturnsWriteErrors(t *testing.T) {
+	runner := &fakeRunner{err: errors.New("Things3 got an error: Application isn't running")}
+	source, _ := newThingsSource(sourceOptions{runner: runner, thingsWritePriorities: true})
+
+	if err := source.(priorityWriter).WritePriorities(CreateTestTasks(1)); err == nil {
+		t.Error("expected the runner's error")
+	}
+}
+
+func TestThingsSourceTagsFocus(t *testing.T) {
+	runner := &fakeRunner{}
+	source, err := newThingsSource(sourceOptions{runner: runner, thingsFocusTag: "Focus (today)", thingsFocusCount: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count := source.Capabilities().FocusCount; count != 2 {
+		t.Fatalf("expected a focus count of 2, got %d", count)
+	}
+
+	if err := source.(focusTagger).TagFocus(CreateTestTasks(2)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	script := runner.calls[0][len(runner.calls[0])-1]
+	for _, expected := range []string{
+		`{"a":"Focus (today)","b":"Focus (today)"}`,
+		`new RegExp("^Focus \\(today\\)$")`,
+	} {
+		if !strings.Contains(script, expected) {
+			t.Errorf("expected %s in the script:\n%s", expected, script)
+		}
+	}
+}
+
+func TestThingsTagScriptUntagsClosedToDos(t *testing.T) {
+	// Completed to-dos stay in the selection until they're logged, and must
+	// not keep their priority or focus tags.
+	script := thingsTagScript(thingsSelection{List: "Today"}, thingsPriorityTag, map[string]string{"a": "sift:1"})
+	if strings.Contains(script, "status") {
+		t.Errorf("expected every selected to-do to be tagged, whatever its status:\n%s", script)
+	}
+	for _, expected := range []string{
+		`const managed = new RegExp("^sift:\\d+$");`,
+		`const tags = {"a":"sift:1"};`,
+		"todos.forEach(todo => {",
+		"const updated = current.filter(tag => !managed.test(tag));",
+		"todo.tagNames = updated.join(', ');",
+	} {
+		if !strings.Contains(script, expected) {
+			t.Errorf("expected %s in the script:\n%s", expected, script)
+		}
+	}
+}
+
+func TestThingsSourceRequiresFocusCount(t *testing.T) {
+	if _, err := newThingsSource(sourceOptions{thingsFocusTag: "Focus"}); err == nil {
+		t.Error("expected an error for a focus tag without a count")
+	}
+	source, _ := newThingsSource(sourceOptions{thingsFocusCount: 3})
+	if source.Capabilities().FocusCount != 0 {
+		t.Error("tagging the focus should be opt-in")
+	}
+}
+
+func TestThingsSourceSetsStatus(t *testing.T) {
+	runner := &fakeRunner{}
+	source, _ := newThingsSource(sourceOptions{runner: runner})
+
+	if err := source.(statusWriter).SetStatus(`2Hf"8Tz`, StatusCanceled); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	script := runner.calls[0][len(runner.calls[0])-1]
+	if !strings.Contains(script, `Things.toDos.byId("2Hf\"8Tz").status = "canceled";`) {
+		t.Errorf("expected the script to cancel the to-do:\n%s", script)
+	}
+}
+
+func TestThingsSourceCreatesTask(t *testing.T) {
+	runner := &fakeRunner{output: `{"id": "9Xk2", "name": "Call \"mom\"", "status": "open"}`}
+	source, _ := newThingsSource(sourceOptions{runner: runner, thingsProject: "Home", thingsTag: "Errand"})
+
+	created, err := source.(taskCreator).CreateTask(`Call "mom"`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if created.ID != "9Xk2" || created.Name != `Call "mom"` || created.Status != StatusOpen {
+		t.Errorf("unexpected task: %+v", created)
+	}
+	script := runner.calls[0][len(runner.calls[0])-1]
+	for _, expected := range []string{`{name: "Call \"mom\""}`, `"project":"Home"`, `"tag":"Errand"`, "container.toDos.push(todo)"} {
+		if !strings.Contains(script, expected) {
+			t.Errorf("expected %s in the script:\n%s", expected, script)
+		}
+	}
+}
+
+func TestThingsSourceCreatesTasksOnlyInListsThatKeepThem(t *testing.T) {
+	for _, tc := range []struct {
+		list      string
+		canCreate bool
+	}{
+		{"Today", true},
+		{"Anytime", true},
+		{"Inbox", true},
+		{"Upcoming", false},
+		{"Someday", false},
+	} {
+		runner := &fakeRunner{output: `{"id": "9Xk2", "name": "Call mom", "status": "open"}`}
+		source, err := newThingsSource(sourceOptions{runner: runner, thingsList: tc.list})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.list, err)
+		}
+		if source.Capabilities().CreateTasks != tc.canCreate {
+			t.Errorf("%s: expected CreateTasks to be %v", tc.list, tc.canCreate)
+		}
+		_, err = source.(taskCreator).CreateTask("Call mom")
+		if tc.canCreate && (err != nil || len(runner.calls) != 1) {
+			t.Errorf("%s: expected the to-do to be created, got %v", tc.list, err)
+		}
+		if !tc.canCreate && (err == nil || len(runner.calls) != 0) {
+			t.Errorf("%s: expected an error without running osascript, got %v", tc.list, err)
+		}
+	}
+}
+
+func TestThingsSourceRenamesTask(t *testing.T) {
+	runner := &fakeRunner{}
+	source, _ := newThingsSource(sourceOptions{runner: runner})
+
+	newID, err := source.(taskRenamer).RenameTask("2Hf8Tz", "Write the report")
+	if err != nil || newID != "2Hf8Tz" {
+		t.Fatalf("expected the ID to stay the same, got %q, %v", newID, err)
+	}
+	script := runner.calls[0][len(runner.calls[0])-1]
+	if !strings.Contains(script, `Things.toDos.byId("2Hf8Tz").name = "Write the report";`) {
+		t.Errorf("expected the script to rename the to-do:\n%s", script)
+	}
+}
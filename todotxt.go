@@ -0,0 +1,366 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// todoTxtSource reads tasks from a todo.txt file. See
+// https://github.com/todotxt/todo.txt for the format.
+type todoTxtSource struct {
+	path string
+	// writePriorities is true when the ranking should be written back to the
+	// file as (A), (B), ... priorities.
+	writePriorities bool
+}
+
+func newTodoTxtSource(opts sourceOptions) (TaskSource, error) {
+	path := opts.todoFile
+	if path == "" {
+		path = os.Getenv("TODO_FILE")
+	}
+	if path == "" {
+		return nil, errors.New("the todotxt source needs --todo-file or $TODO_FILE")
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	return todoTxtSource{path: path, writePriorities: opts.todoWritePriorities}, nil
+}
+
+func (s todoTxtSource) ID() string {
+	return "todotxt:" + s.path
+}
+
+func (s todoTxtSource) Capabilities() sourceCapabilities {
+	return sourceCapabilities{
+		Watch:           []string{s.path},
+		WritePriorities: s.writePriorities,
+		WriteStatuses:   []string{StatusCompleted},
+		CreateTasks:     true,
+		RenameTasks:     true,
+	}
+}
+
+func (s todoTxtSource) Fetch() ([]task, error) {
+	lines, err := readLines(s.path)
+	if err != nil {
+		return nil, err
+	}
+	var tasks []task
+	ids := newStableIDs()
+	for _, line := range lines {
+		item, ok := parseTodoTxtLine(line)
+		if !ok {
+			continue
+		}
+		tasks = append(tasks, item.toTask(ids.next(item.description)))
+	}
+	return tasks, nil
+}
+
+// WritePriorities gives the ordered tasks the priorities (A) to (Z) and
+// removes the priority from every other open task. Tasks after the 26th have
+// no priority, which todo.txt tools sort after every prioritized task.
+func (s todoTxtSource) WritePriorities(ordered []task) error {
+	priorities := make(map[string]byte)
+	for i, t := range ordered {
+		if i >= 26 {
+			break
+		}
+		priorities[t.ID] = byte('A' + i)
+	}
+
+	lines, err := readLines(s.path)
+	if err != nil {
+		return err
+	}
+	ids := newStableIDs()
+	changed := false
+	for i, line := range lines {
+		item, ok := parseTodoTxtLine(line)
+		if !ok {
+			continue
+		}
+		id := ids.next(item.description)
+		if item.completed {
+			// Leave completed tasks as they are.
+			continue
+		}
+		if newLine := setTodoTxtPriority(line, priorities[id]); newLine != line {
+			lines[i] = newLine
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return writeLines(s.path, lines)
+}
+
+// SetStatus completes the open task with the given ID, with today as its
+// completion date. Its priority is kept as a pri: tag. todo.txt has no
+// canceled tasks, so they can't be canceled.
+func (s todoTxtSource) SetStatus(id, status string) error {
+	if status != StatusCompleted {
+		return errors.New("todo.txt tasks can only be completed")
+	}
+	lines, err := readLines(s.path)
+	if err != nil {
+		return err
+	}
+	ids := newStableIDs()
+	for i, line := range lines {
+		item, ok := parseTodoTxtLine(line)
+		if !ok || ids.next(item.description) != id {
+			continue
+		}
+		if item.completed {
+			return nil
+		}
+		line = setTodoTxtPriority(line, 0)
+		if item.priority != 0 {
+			line += fmt.Sprintf(" pri:%c", item.priority)
+		}
+		lines[i] = "x " + now().Format(time.DateOnly) + " " + line
+		return writeLines(s.path, lines)
+	}
+	return fmt.Errorf("task %s is not in %s", id, s.path)
+}
+
+// CreateTask appends a line with the task to the file.
+func (s todoTxtSource) CreateTask(name string) (task, error) {
+	item, ok := parseTodoTxtLine(name)
+	if !ok || item.completed {
+		return task{}, fmt.Errorf("%q is not an open todo.txt task", name)
+	}
+	lines, err := readLines(s.path)
+	if err != nil {
+		return task{}, err
+	}
+	// Tasks with the same text get a counter in their ID, so the new one is
+	// numbered after the existing ones.
+	ids := newStableIDs()
+	for _, line := range lines {
+		if existing, ok := parseTodoTxtLine(line); ok {
+			ids.next(existing.description)
+		}
+	}
+	if err := writeLines(s.path, append(lines, name)); err != nil {
+		return task{}, err
+	}
+	return item.toTask(ids.next(item.description)), nil
+}
+
+// RenameTask replaces the description of the task with the given ID, keeping
+// its priority, dates, and completion. Its ID changes with the description.
+func (s todoTxtSource) RenameTask(id, name string) (string, error) {
+	lines, err := readLines(s.path)
+	if err != nil {
+		return "", err
+	}
+	ids := newStableIDs()
+	renamed := -1
+	for i, line := range lines {
+		item, ok := parseTodoTxtLine(line)
+		if !ok || ids.next(item.description) != id {
+			continue
+		}
+		newLine := item.prefix + name
+		if tag := todoTxtPriTag.FindString(line); tag != "" {
+			newLine += " " + strings.TrimSpace(tag)
+		}
+		if _, ok := parseTodoTxtLine(newLine); !ok {
+			return "", fmt.Errorf("%q is not a valid todo.txt task", name)
+		}
+		lines[i] = newLine
+		renamed = i
+		break
+	}
+	if renamed == -1 {
+		return "", fmt.Errorf("task %s is not in %s", id, s.path)
+	}
+
+	// Tasks with the same text get a counter in their ID, so the new ID
+	// depends on the lines before it.
+	var newID string
+	ids = newStableIDs()
+	for i, line := range lines {
+		if item, ok := parseTodoTxtLine(line); ok {
+			if next := ids.next(item.description); i == renamed {
+				newID = next
+			}
+		}
+	}
+	if err := writeLines(s.path, lines); err != nil {
+		return "", err
+	}
+	return newID, nil
+}
+
+// todoTxtItem is a parsed line of a todo.txt file.
+type todoTxtItem struct {
+	completed bool
+	// priority is 'A' to 'Z', or 0 if the task has no priority.
+	priority       byte
+	completionDate string
+	creationDate   string
+	// prefix is the part of the line before the description, i.e. the
+	// completion mark, the priority, and the dates.
+	prefix string
+	// description is the rest of the line, including projects and contexts.
+	description string
+	projects    []string
+	contexts    []string
+}
+
+var (
+	todoTxtPriority = regexp.MustCompile(`^\(([A-Z])\) `)
+	todoTxtDate     = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}) `)
+	todoTxtPriTag   = regexp.MustCompile(`(^|\s)pri:[A-Z](\s|$)`)
+)
+
+// parseTodoTxtLine parses a line of a todo.txt file. It returns false for
+// lines without a task.
+func parseTodoTxtLine(line string) (todoTxtItem, bool) {
+	var item todoTxtItem
+	trimmed := strings.TrimRight(line, "\r")
+	rest := trimmed
+	if strings.TrimSpace(rest) == "" {
+		return item, false
+	}
+
+	if strings.HasPrefix(rest, "x ") {
+		item.completed = true
+		rest = rest[2:]
+		if m := todoTxtDate.FindStringSubmatch(rest); m != nil {
+			item.completionDate = m[1]
+			rest = rest[len(m[0]):]
+		}
+	} else if m := todoTxtPriority.FindStringSubmatch(rest); m != nil {
+		item.priority = m[1][0]
+		rest = rest[len(m[0]):]
+	}
+	if m := todoTxtDate.FindStringSubmatch(rest); m != nil {
+		item.creationDate = m[1]
+		rest = rest[len(m[0]):]
+	}
+	item.prefix = trimmed[:len(trimmed)-len(rest)]
+
+	// Some tools keep the priority of completed tasks as a pri: tag. It's
+	// dropped so the task keeps the ID it had before it was completed.
+	item.description = strings.TrimSpace(todoTxtPriTag.ReplaceAllString(rest, ""))
+	if item.description == "" {
+		return item, false
+	}
+	for _, word := range strings.Fields(item.description) {
+		switch {
+		case len(word) > 1 && word[0] == '+':
+			item.projects = append(item.projects, word[1:])
+		case len(word) > 1 && word[0] == '@':
+			item.contexts = append(item.contexts, word[1:])
+		}
+	}
+	return item, true
+}
+
+func (item todoTxtItem) toTask(id string) task {
+	t := task{
+		ID:     id,
+		Name:   item.description,
+		Status: StatusOpen,
+		Tags:   item.contexts,
+	}
+	if item.completed {
+		t.Status = StatusCompleted
+	}
+	if len(item.projects) > 0 {
+		t.Project = item.projects[0]
+	}
+	return t
+}
+
+// setTodoTxtPriority replaces the priority of an open task's line. A priority
+// of 0 removes it.
+func setTodoTxtPriority(line string, priority byte) string {
+	line = todoTxtPriority.ReplaceAllString(line, "")
+	if priority == 0 {
+		return line
+	}
+	return fmt.Sprintf("(%c) %s", priority, line)
+}
+
+// stableIDs derives task IDs from the text of the tasks, so they survive
+// lines being moved, completed, or reprioritized. Tasks with the same text get
+// a counter appended, in the order they appear.
+type stableIDs map[string]int
+
+func newStableIDs() stableIDs {
+	return make(stableIDs)
+}
+
+func (ids stableIDs) next(text string) string {
+	sum := sha1.Sum([]byte(text))
+	id := hex.EncodeToString(sum[:])[:12]
+	ids[id]++
+	if n := ids[id]; n > 1 {
+		return fmt.Sprintf("%s-%d", id, n)
+	}
+	return id
+}
+
+// readLines reads a text file into lines, without their line endings.
+func readLines(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
+
+// writeLines replaces the file at path with the given lines. It writes to a
+// temporary file first so other programs never see a half-written file.
+func writeLines(path string, lines []string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	content := strings.Join(lines, "\n")
+	if len(lines) > 0 {
+		content += "\n"
+	}
+	if _, err := tmp.WriteString(content); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
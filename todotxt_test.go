@@ -0,0 +1,282 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeTestFile writes content to a file in a temporary directory and returns
+// its path.
+func writeTestFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	return path
+}
+
+func TestParseTodoTxtLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		ok       bool
+		expected todoTxtItem
+	}{
+		{
+			name: "blank line",
+			line: "   ",
+			ok:   false,
+		},
+		{
+			name:     "plain task",
+			line:     "Call mom",
+			ok:       true,
+			expected: todoTxtItem{description: "Call mom"},
+		},
+		{
+			name: "priority and creation date",
+			line: "(A) 2025-01-02 Call mom +family @phone",
+			ok:   true,
+			expected: todoTxtItem{
+				priority:     'A',
+				creationDate: "2025-01-02",
+				description:  "Call mom +family @phone",
+				projects:     []string{"family"},
+				contexts:     []string{"phone"},
+			},
+		},
+		{
+			name: "completed with dates",
+			line: "x 2025-01-03 2025-01-02 Call mom pri:A",
+			ok:   true,
+			expected: todoTxtItem{
+				completed:      true,
+				completionDate: "2025-01-03",
+				creationDate:   "2025-01-02",
+				description:    "Call mom",
+			},
+		},
+		{
+			name:     "lowercase x is not a completion marker",
+			line:     "xylophone lessons",
+			ok:       true,
+			expected: todoTxtItem{description: "xylophone lessons"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item, ok := parseTodoTxtLine(tt.line)
+			if ok != tt.ok {
+				t.Fatalf("expected ok %v, got %v", tt.ok, ok)
+			}
+			if !ok {
+				return
+			}
+			if item.completed != tt.expected.completed ||
+				item.priority != tt.expected.priority ||
+				item.completionDate != tt.expected.completionDate ||
+				item.creationDate != tt.expected.creationDate ||
+				item.description != tt.expected.description ||
+				strings.Join(item.projects, ",") != strings.Join(tt.expected.projects, ",") ||
+				strings.Join(item.contexts, ",") != strings.Join(tt.expected.contexts, ",") {
+				t.Errorf("expected %+v, got %+v", tt.expected, item)
+			}
+		})
+	}
+}
+
+func TestTodoTxtSourceFetch(t *testing.T) {
+	path := writeTestFile(t, "todo.txt", "(B) Write report +work @office\n\nx 2025-01-03 Buy milk\nCall mom\n")
+	source := todoTxtSource{path: path}
+
+	tasks, err := source.Fetch()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(tasks) != 3 {
+		t.Fatalf("expected 3 tasks, got %d", len(tasks))
+	}
+	if tasks[0].Name != "Write report +work @office" || tasks[0].Project != "work" ||
+		len(tasks[0].Tags) != 1 || tasks[0].Tags[0] != "office" {
+		t.Errorf("unexpected first task: %+v", tasks[0])
+	}
+	if tasks[1].Status != StatusCompleted {
+		t.Errorf("expected completed task, got %s", tasks[1].Status)
+	}
+	if tasks[2].Status != StatusOpen {
+		t.Errorf("expected open task, got %s", tasks[2].Status)
+	}
+}
+
+func TestTodoTxtIDsSurviveCompletionAndReordering(t *testing.T) {
+	before := writeTestFile(t, "todo.txt", "2025-01-01 Buy milk\n(A) Call mom\n")
+	after := writeTestFile(t, "todo.txt", "(C) Call mom\nx 2025-01-05 2025-01-01 Buy milk\n")
+
+	beforeTasks, err := todoTxtSource{path: before}.Fetch()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	afterTasks, err := todoTxtSource{path: after}.Fetch()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if beforeTasks[0].ID != afterTasks[1].ID {
+		t.Error("completing a task should not change its ID")
+	}
+	if beforeTasks[1].ID != afterTasks[0].ID {
+		t.Error("moving or reprioritizing a task should not change its ID")
+	}
+}
+
+func TestTodoTxtDuplicateTasksGetDistinctIDs(t *testing.T) {
+	path := writeTestFile(t, "todo.txt", "Stretch\nStretch\n")
+	tasks, err := todoTxtSource{path: path}.Fetch()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tasks[0].ID == tasks[1].ID {
+		t.Error("duplicate tasks should have distinct IDs")
+	}
+}
+
+func TestTodoTxtWritePriorities(t *testing.T) {
+	path := writeTestFile(t, "todo.txt", "(A) 2025-01-01 Buy milk\nCall mom\nx 2025-01-02 Done already\nWater plants\n")
+	source := todoTxtSource{path: path, writePriorities: true}
+	tasks, err := source.Fetch()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Call mom, then Buy milk. Water plants is left without a priority.
+	if err := source.WritePriorities([]task{tasks[1], tasks[0]}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "(B) 2025-01-01 Buy milk\n(A) Call mom\nx 2025-01-02 Done already\nWater plants\n"
+	if string(data) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, string(data))
+	}
+
+	// IDs are unchanged after the write.
+	newTasks, err := source.Fetch()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := range tasks {
+		if tasks[i].ID != newTasks[i].ID {
+			t.Errorf("task %d changed ID after writing priorities", i)
+		}
+	}
+}
+
+func TestNewTodoTxtSourceRequiresFile(t *testing.T) {
+	t.Setenv("TODO_FILE", "")
+	if _, err := newTodoTxtSource(sourceOptions{}); err == nil {
+		t.Error("expected an error without a todo.txt file")
+	}
+
+	t.Setenv("TODO_FILE", "/tmp/todo.txt")
+	source, err := newTodoTxtSource(sourceOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if source.ID() != "todotxt:/tmp/todo.txt" {
+		t.Errorf("unexpected ID %s", source.ID())
+	}
+}
+
+func TestTodoTxtSetStatusCompletesTask(t *testing.T) {
+	SetTestClock(t, time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC))
+	path := writeTestFile(t, "todo.txt", "(A) Call mom @phone\nBuy milk\n")
+	source := todoTxtSource{path: path}
+	tasks, err := source.Fetch()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := source.SetStatus(tasks[0].ID, StatusCompleted); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if expected := "x 2024-05-06 Call mom @phone pri:A\nBuy milk\n"; string(data) != expected {
+		t.Errorf("expected %q, got %q", expected, string(data))
+	}
+	completed, _ := source.Fetch()
+	if completed[0].ID != tasks[0].ID || completed[0].Status != StatusCompleted {
+		t.Errorf("expected the task to keep its ID and be completed, got %+v", completed[0])
+	}
+
+	if err := source.SetStatus(tasks[1].ID, StatusCanceled); err == nil {
+		t.Error("expected an error, since todo.txt has no canceled tasks")
+	}
+	if statuses := source.Capabilities().WriteStatuses; !slices.Equal(statuses, []string{StatusCompleted}) {
+		t.Errorf("expected only completing to be offered, got %v", statuses)
+	}
+	if err := source.SetStatus("missing", StatusCompleted); err == nil {
+		t.Error("expected an error for a missing task")
+	}
+}
+
+func TestTodoTxtCreateTaskAppendsLine(t *testing.T) {
+	path := writeTestFile(t, "todo.txt", "Buy milk\nx Buy milk")
+	source := todoTxtSource{path: path}
+
+	created, err := source.CreateTask("Buy milk +groceries")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if expected := "Buy milk\nx Buy milk\nBuy milk +groceries\n"; string(data) != expected {
+		t.Errorf("expected %q, got %q", expected, string(data))
+	}
+	tasks, _ := source.Fetch()
+	if created.ID != tasks[2].ID || created.Project != "groceries" || created.Status != StatusOpen {
+		t.Errorf("expected the created task to match the fetched one, got %+v and %+v", created, tasks[2])
+	}
+
+	duplicate, err := source.CreateTask("Buy milk")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tasks, _ = source.Fetch()
+	if duplicate.ID != tasks[3].ID {
+		t.Errorf("expected the duplicate to get the ID %s, got %s", tasks[3].ID, duplicate.ID)
+	}
+}
+
+func TestTodoTxtRenameTaskKeepsPriorityAndDates(t *testing.T) {
+	path := writeTestFile(t, "todo.txt", "(B) 2024-05-01 Call mom @phone\nx 2024-05-02 2024-05-01 Call mom pri:A\n")
+	source := todoTxtSource{path: path}
+	tasks, _ := source.Fetch()
+
+	newID, err := source.RenameTask(tasks[0].ID, "Call mom about the trip @phone")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := source.RenameTask(tasks[1].ID, "Call dad"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	expected := "(B) 2024-05-01 Call mom about the trip @phone\nx 2024-05-02 2024-05-01 Call dad pri:A\n"
+	if string(data) != expected {
+		t.Errorf("expected %q, got %q", expected, string(data))
+	}
+	renamed, _ := source.Fetch()
+	if newID != renamed[0].ID {
+		t.Errorf("expected the new ID %s, got %s", renamed[0].ID, newID)
+	}
+
+	if _, err := source.RenameTask("missing", "Call dad"); err == nil {
+		t.Error("expected an error for a missing task")
+	}
+}
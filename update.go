@@ -1,6 +1,9 @@
 package main
 
 import (
+	"slices"
+	"time"
+
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	lipgloss "github.com/charmbracelet/lipgloss"
@@ -12,46 +15,38 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 
 	case tea.KeyMsg:
+		// Any key dismisses the error of the last write.
+		m.writeErr = nil
 		switch {
 		case key.Matches(msg, DefaultKeyMap.Quit):
 			return m, tea.Quit
+		case m.prompt != promptNone:
+			switch {
+			case key.Matches(msg, DefaultKeyMap.Confirm):
+				cmds = append(cmds, m.submitPrompt())
+			case key.Matches(msg, DefaultKeyMap.Dismiss):
+				m.closePrompt()
+			case m.prompt == promptClose:
+				// Nothing is typed into the close prompt.
+				if key.Matches(msg, DefaultKeyMap.NextTask) {
+					m.nextCloseTarget()
+				}
+			default:
+				var cmd tea.Cmd
+				m.input, cmd = m.input.Update(msg)
+				cmds = append(cmds, cmd)
+			}
+			// Keys that are typed must not scroll the viewport.
+			return m, tea.Batch(cmds...)
 		case key.Matches(msg, DefaultKeyMap.ChooseLeft):
 			if m.taskB != nil && m.taskA != nil {
-				for i := range m.allTasks {
-					if m.allTasks[i].ID == m.taskB.ID {
-						// Get current parent before changing it
-						var previousParentID string
-						if m.allTasks[i].ParentID != nil {
-							previousParentID = *m.allTasks[i].ParentID
-						}
-						// Set its parent to the left side task
-						m.allTasks[i].ParentID = &m.taskA.ID
-						// Add to history
-						m = m.addToHistory(m.taskB.ID, previousParentID, m.taskA.ID, m.taskB.ID)
-						m.updateComparisonTasks()
-						break
-					}
-				}
-				cmds = append(cmds, storeTasks(m.allTasks))
+				m.chooseTask(m.taskA.ID, m.taskB.ID)
+				cmds = append(cmds, storeTasks(m.allTasks), m.writePrioritiesIfComplete(), m.tagFocusIfChanged())
 			}
 		case key.Matches(msg, DefaultKeyMap.ChooseRight):
 			if m.taskA != nil && m.taskB != nil {
-				for i := range m.allTasks {
-					if m.allTasks[i].ID == m.taskA.ID {
-						// Get current parent before changing it
-						var previousParentID string
-						if m.allTasks[i].ParentID != nil {
-							previousParentID = *m.allTasks[i].ParentID
-						}
-						// Set its parent to the right side task
-						m.allTasks[i].ParentID = &m.taskB.ID
-						// Add to history
-						m = m.addToHistory(m.taskA.ID, previousParentID, m.taskA.ID, m.taskB.ID)
-						m.updateComparisonTasks()
-						break
-					}
-				}
-				cmds = append(cmds, storeTasks(m.allTasks))
+				m.chooseTask(m.taskB.ID, m.taskA.ID)
+				cmds = append(cmds, storeTasks(m.allTasks), m.writePrioritiesIfComplete(), m.tagFocusIfChanged())
 			}
 		case key.Matches(msg, DefaultKeyMap.Undo):
 			if m.canUndo() {
@@ -59,26 +54,38 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.history = m.history[:len(m.history)-1]
 
 				// Restore the child's previous parent
-				for i := range m.allTasks {
-					if m.allTasks[i].ID == lastDecision.childID {
-						if lastDecision.previousParentID == "" {
-							m.allTasks[i].ParentID = nil
-						} else {
-							m.allTasks[i].ParentID = &lastDecision.previousParentID
-						}
-						break
-					}
-				}
+				m.ranker.Undo(m.allTasks, lastDecision)
 				m.updateComparisonTasksWithPreference(lastDecision.taskAID, lastDecision.taskBID)
-				cmds = append(cmds, storeTasks(m.allTasks))
+				cmds = append(cmds, storeTasks(m.allTasks), m.tagFocusIfChanged())
 			}
 		case key.Matches(msg, DefaultKeyMap.Reset):
 			// Reset the tasks.
-			for i := range m.allTasks {
-				m.allTasks[i].ParentID = nil
-			}
+			m.ranker.Reset(m.allTasks)
 			m.updateComparisonTasks()
-			cmds = append(cmds, storeTasks(m.allTasks))
+			cmds = append(cmds, storeTasks(m.allTasks), m.tagFocusIfChanged())
+		case key.Matches(msg, DefaultKeyMap.Retry):
+			if m.source != nil {
+				// Fetch right away, even while backing off or while another
+				// fetch is stuck.
+				m.retryAt = time.Time{}
+				cmds = append(cmds, m.startFetch())
+			}
+		case key.Matches(msg, DefaultKeyMap.Complete):
+			cmds = append(cmds, m.openClosePrompt(StatusCompleted))
+		case key.Matches(msg, DefaultKeyMap.Cancel):
+			cmds = append(cmds, m.openClosePrompt(StatusCanceled))
+		case key.Matches(msg, DefaultKeyMap.AddTask):
+			cmds = append(cmds, m.openAddPrompt())
+		case key.Matches(msg, DefaultKeyMap.RenameLeft):
+			cmds = append(cmds, m.openRenamePrompt(m.taskA))
+		case key.Matches(msg, DefaultKeyMap.RenameRight):
+			cmds = append(cmds, m.openRenamePrompt(m.taskB))
+		case key.Matches(msg, DefaultKeyMap.SnoozeLeft):
+			cmds = append(cmds, m.openSnoozePrompt(m.taskA))
+		case key.Matches(msg, DefaultKeyMap.SnoozeRight):
+			cmds = append(cmds, m.openSnoozePrompt(m.taskB))
+		case key.Matches(msg, DefaultKeyMap.Details):
+			m.showDetails = !m.showDetails
 		case key.Matches(msg, DefaultKeyMap.Help):
 			m.help.ShowAll = !m.help.ShowAll
 			m.viewport.Height = m.height - lipgloss.Height(m.helpView())
@@ -92,7 +99,31 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.help.Width = msg.Width
 
 	case tasksMsg:
+		if msg.Seq < m.fetchSeq {
+			// A newer fetch has been started since, so these tasks may be
+			// outdated.
+			Logger.Debugf("Discarding tasks of fetch %d, latest is %d", msg.Seq, m.fetchSeq)
+			break
+		}
+		m.fetching = false
+		cmds = append(cmds, m.fetchIfChanged())
+		m.stale = false
+		m.lastSync = now()
+		m.fetchErr = nil
+		m.fetchFailures = 0
+		m.retryAt = time.Time{}
+		if msg.Fingerprint != "" && msg.Fingerprint == m.fingerprint {
+			// Nothing changed since the previous fetch, so there's nothing to
+			// sync or render.
+			Logger.Debugf("Tasks of fetch %d are unchanged", msg.Seq)
+			// Tags that failed before are retried, though.
+			cmds = append(cmds, m.tagFocusIfChanged())
+			return m, tea.Batch(cmds...)
+		}
+		m.fingerprint = msg.Fingerprint
+		cmds = append(cmds, storeCachedTasks(msg.Tasks))
 		m.allTasks = syncTasks(m.allTasks, msg.Tasks)
+		cmds = append(cmds, m.applySnoozes())
 		if m.highlightIndex >= len(m.allTasks) {
 			// The new list of tasks is shorter.
 			m.highlightIndex = len(m.allTasks) - 1
@@ -100,6 +131,30 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.comparisonTasksNeedUpdated() {
 			m.updateComparisonTasks()
 		}
+		// Completed tasks drop out of the top.
+		cmds = append(cmds, m.tagFocusIfChanged())
+
+	case cachedTasksMsg:
+		if len(m.allTasks) > 0 || !m.lastSync.IsZero() {
+			// The source was fetched already.
+			break
+		}
+		m.allTasks = msg.Tasks
+		m.stale = true
+		cmds = append(cmds, m.applySnoozes())
+		if m.comparisonTasksNeedUpdated() {
+			m.updateComparisonTasks()
+		}
+
+	case snoozesMsg:
+		m.snoozed = msg.Snoozed
+		for _, until := range m.snoozed {
+			cmds = append(cmds, wakeUpAt(until))
+		}
+		cmds = append(cmds, m.applySnoozes())
+
+	case snoozeExpiredMsg:
+		cmds = append(cmds, m.applySnoozes(), storeTasks(m.allTasks), m.tagFocusIfChanged())
 
 	case loadRelationshipsMsg:
 		// This happens during startup sequence after tasksMsg
@@ -108,17 +163,126 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case initialTasksMsg:
 		// Final step of startup sequence - tasks with relationships applied
 		m.allTasks = msg.Tasks
+		m.loaded = true
+		cmds = append(cmds, m.applySnoozes())
 		if m.comparisonTasksNeedUpdated() {
 			m.updateComparisonTasks()
 		}
+		cmds = append(cmds, m.tagFocusIfChanged())
+		// Startup is done, so start refreshing.
+		cmds = append(cmds, m.nextFetchTick(), m.waitForChange())
 
 	case fetchMsg:
-		cmd := tea.Batch(
-			getTasksFromThings,
-			// Start the next fetch timer.
-			getFetchTick(),
-		)
-		cmds = append(cmds, cmd)
+		switch {
+		case m.fetching:
+			// Let the running fetch finish instead of piling up more of them
+			// behind a slow source.
+			Logger.Debugf("Fetch %d is still running, skipping refresh", m.fetchSeq)
+		case m.writes > 0:
+			// The writes are fetched once they're done.
+			Logger.Debugf("Writes are still running, skipping refresh")
+		case now().Before(m.retryAt):
+			// Back off while the source keeps failing.
+			Logger.Debugf("Backing off until %s", m.retryAt.Format(time.TimeOnly))
+		default:
+			cmds = append(cmds, m.startFetch())
+		}
+		// Start the next fetch timer.
+		cmds = append(cmds, m.nextFetchTick())
+
+	case sourceChangedMsg:
+		switch {
+		case m.writes > 0:
+			// The writes are fetched once they're done, along with this change.
+		case m.fetching:
+			// The running fetch may have read the files before they changed.
+			m.changedWhileFetching = true
+		default:
+			cmds = append(cmds, m.startFetch())
+		}
+		cmds = append(cmds, m.waitForChange())
+
+	case fetchErrorMsg:
+		Logger.Error(msg.err)
+		if msg.Seq != m.fetchSeq {
+			// A newer fetch has been started since.
+			break
+		}
+		m.fetching = false
+		cmds = append(cmds, m.fetchIfChanged())
+		m.fetchErr = msg.err
+		m.fetchFailures++
+		m.retryAt = now().Add(fetchBackoff(m.fetchFailures))
+
+	case statusWrittenMsg:
+		if msg.err != nil {
+			Logger.Error(msg.err)
+			m.writeErr = msg.err
+			// Reopen the task and give its children their parent back.
+			for _, previous := range msg.previous {
+				if t := getTaskByID(previous.ID, m.allTasks); t != nil {
+					t.Status = previous.Status
+					t.ParentID = previous.ParentID
+				}
+			}
+			if m.comparisonTasksNeedUpdated() {
+				m.updateComparisonTasks()
+			}
+			cmds = append(cmds, storeTasks(m.allTasks), m.tagFocusIfChanged())
+		}
+		cmds = append(cmds, m.endWrite())
+
+	case taskRenamedMsg:
+		if msg.err != nil {
+			Logger.Error(msg.err)
+			m.writeErr = msg.err
+			m.setTaskName(msg.id, msg.previousName)
+		} else if msg.newID != msg.id {
+			// The source derives IDs from names, so keep the priorities of the
+			// task under its new ID.
+			m.replaceTaskID(msg.id, msg.newID)
+			cmds = append(cmds, storeTasks(m.allTasks))
+		}
+		cmds = append(cmds, m.endWrite())
+
+	case taskCreatedMsg:
+		if msg.err != nil {
+			Logger.Error(msg.err)
+			m.writeErr = msg.err
+		} else if getTaskByID(msg.task.ID, m.allTasks) == nil {
+			// Add the task as a root, so it's compared with the others right
+			// away instead of after the next fetch.
+			created := msg.task
+			created.ParentID = nil
+			m.allTasks = append(m.allTasks, created)
+			if m.comparisonTasksNeedUpdated() {
+				m.updateComparisonTasks()
+			}
+			cmds = append(cmds, m.tagFocusIfChanged())
+		}
+		cmds = append(cmds, m.endWrite())
+
+	case focusTaggedMsg:
+		if m.tagging && slices.Equal(msg.ids, m.taggingIDs) {
+			m.tagging = false
+		}
+		if msg.err != nil {
+			// Tag them again on the next change or refresh.
+			m.focusTagged = false
+			m.writeErr = msg.err
+			break
+		}
+		m.focusIDs = msg.ids
+		m.focusTagged = true
+		if !m.tagging {
+			// The top may have changed while it was being tagged.
+			cmds = append(cmds, m.tagFocusIfChanged())
+		}
+
+	case prioritiesWrittenMsg:
+		if msg.err != nil {
+			m.writeErr = msg.err
+		}
 
 	case errorMsg:
 		Logger.Error(msg.err)
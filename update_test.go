@@ -1,7 +1,11 @@
 package main
 
 import (
+	"errors"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -905,3 +909,955 @@ func TestUndoFallsBackWhenTasksUnavailable(t *testing.T) {
 		t.Error("Should have tasks available for comparison at highest level")
 	}
 }
+
+func TestUpdateFetchMsgFetchesFromSource(t *testing.T) {
+	tasks := CreateTestTasks(3)
+	m := CreateTestModel(tasks)
+	source := m.source.(*fakeSource)
+
+	newModel, cmd := m.Update(fetchMsg{})
+	m = newModel.(model)
+	if cmd == nil {
+		t.Fatal("fetchMsg should return a fetch command")
+	}
+
+	var fetched *tasksMsg
+	for _, msg := range runCmds(cmd) {
+		if msg, ok := msg.(tasksMsg); ok {
+			fetched = &msg
+		}
+	}
+	if fetched == nil {
+		t.Fatal("expected the command to fetch the tasks")
+	}
+	if source.fetches != 1 {
+		t.Errorf("expected the source to be fetched once, got %d", source.fetches)
+	}
+	if fetched.Seq != m.fetchSeq || len(fetched.Tasks) != len(tasks) {
+		t.Errorf("expected %d tasks from fetch %d, got %d from fetch %d", len(tasks), m.fetchSeq, len(fetched.Tasks), fetched.Seq)
+	}
+
+	newModel, _ = m.Update(*fetched)
+	concreteModel := newModel.(model)
+	if len(concreteModel.allTasks) != len(tasks) {
+		t.Errorf("expected %d tasks in model, got %d", len(tasks), len(concreteModel.allTasks))
+	}
+	AssertModelHasComparisonTasks(t, concreteModel)
+}
+
+func TestFetchTasksReturnsFetchErrorMsgWhenSourceFails(t *testing.T) {
+	source := newFakeSource(nil)
+	source.err = errors.New("source is unavailable")
+
+	msg := fetchTasks(source, 3)()
+	errMsg, ok := msg.(fetchErrorMsg)
+	if !ok {
+		t.Fatalf("expected fetchErrorMsg, got %T", msg)
+	}
+	if errMsg.Seq != 3 {
+		t.Errorf("expected sequence number 3, got %d", errMsg.Seq)
+	}
+}
+
+func TestUpdateFetchMsgDoesNotScheduleTickWithoutPolling(t *testing.T) {
+	m := CreateTestModel(CreateTestTasks(3))
+	m.source.(*fakeSource).capabilities.Polling = false
+
+	_, cmd := m.Update(fetchMsg{})
+	// Without a tick, the fetch is the only command, so tea.Batch returns it
+	// as-is instead of a tea.BatchMsg.
+	msg := cmd()
+	if _, ok := msg.(tasksMsg); !ok {
+		t.Errorf("expected only the fetch command, got %T", msg)
+	}
+}
+
+func TestCompletingTheRankingWritesPriorities(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	tasks := CreateTestTasks(2)
+	m := CreateTestModel(tasks)
+	source := m.source.(*fakeSource)
+	source.capabilities.WritePriorities = true
+	m.allTasks = tasks
+	m.taskA = &tasks[0]
+	m.taskB = &tasks[1]
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	for _, msg := range cmd().(tea.BatchMsg) {
+		if msg != nil {
+			msg()
+		}
+	}
+
+	if len(source.written) != 1 {
+		t.Fatalf("expected priorities to be written once, got %d", len(source.written))
+	}
+	ordered := source.written[0]
+	if len(ordered) != 2 || ordered[0].ID != tasks[0].ID || ordered[1].ID != tasks[1].ID {
+		t.Errorf("unexpected order: %+v", ordered)
+	}
+}
+
+func TestFailedPriorityWriteIsShown(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	tasks := CreateTestTasks(2)
+	m := CreateTestModel(tasks)
+	source := m.source.(*fakeSource)
+	source.capabilities.WritePriorities = true
+	source.err = errors.New("Things3 got an error: Application isn't running")
+	m.allTasks = tasks
+	m.taskA = &tasks[0]
+	m.taskB = &tasks[1]
+
+	newModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	m = newModel.(model)
+	var written *prioritiesWrittenMsg
+	for _, msg := range runCmds(cmd) {
+		if msg, ok := msg.(prioritiesWrittenMsg); ok {
+			written = &msg
+		}
+	}
+	if written == nil {
+		t.Fatal("expected the priorities to be written")
+	}
+
+	newModel, _ = m.Update(*written)
+	m = newModel.(model)
+	if m.writeErr == nil || !strings.Contains(m.writeErr.Error(), "Application isn't running") {
+		t.Errorf("expected the error to be shown, got %v", m.writeErr)
+	}
+}
+
+func TestPrioritiesAreNotWrittenWithoutOptIn(t *testing.T) {
+	tasks := CreateTestTasks(2)
+	m := CreateTestModel(tasks)
+	source := m.source.(*fakeSource)
+	m.allTasks = tasks
+	m.taskA = &tasks[0]
+	m.taskB = &tasks[1]
+
+	if cmd := m.writePrioritiesIfComplete(); cmd != nil {
+		t.Error("expected no command when writing priorities is disabled")
+	}
+	if len(source.written) != 0 {
+		t.Error("priorities should not be written")
+	}
+}
+
+func TestUpdateHandlesDetailsKey(t *testing.T) {
+	m := initialModel()
+	keyMsg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}}
+
+	newModel, _ := m.Update(keyMsg)
+	if !newModel.(model).showDetails {
+		t.Error("details should be shown after pressing d")
+	}
+	newModel, _ = newModel.Update(keyMsg)
+	if newModel.(model).showDetails {
+		t.Error("details should be hidden after pressing d again")
+	}
+}
+
+func TestFetchMsgDoesNotStartAnotherFetchWhileOneIsRunning(t *testing.T) {
+	m := CreateTestModel(CreateTestTasks(3))
+
+	newModel, _ := m.Update(fetchMsg{})
+	m = newModel.(model)
+	if !m.fetching || m.fetchSeq != 1 {
+		t.Fatalf("expected fetch 1 to be running, got fetching=%v seq=%d", m.fetching, m.fetchSeq)
+	}
+
+	newModel, _ = m.Update(fetchMsg{})
+	m = newModel.(model)
+	if m.fetchSeq != 1 {
+		t.Errorf("a second fetch should not start while the first is running, got seq %d", m.fetchSeq)
+	}
+
+	newModel, _ = m.Update(tasksMsg{Tasks: CreateTestTasks(3), Seq: 1})
+	m = newModel.(model)
+	if m.fetching {
+		t.Error("the fetch should be done after its tasks arrive")
+	}
+
+	newModel, _ = m.Update(fetchMsg{})
+	m = newModel.(model)
+	if m.fetchSeq != 2 {
+		t.Errorf("a new fetch should start after the previous one finished, got seq %d", m.fetchSeq)
+	}
+}
+
+func TestTasksFromOlderFetchesAreDiscarded(t *testing.T) {
+	m := CreateTestModel(nil)
+	m.allTasks = CreateTestTasks(3)
+	m.fetchSeq = 2
+	m.fetching = true
+
+	newModel, _ := m.Update(tasksMsg{Tasks: CreateTestTasks(1), Seq: 1})
+	m = newModel.(model)
+	if len(m.allTasks) != 3 {
+		t.Errorf("tasks of an older fetch should be discarded, got %d tasks", len(m.allTasks))
+	}
+	if !m.fetching {
+		t.Error("the latest fetch should still be running")
+	}
+
+	newModel, _ = m.Update(tasksMsg{Tasks: CreateTestTasks(5), Seq: 2})
+	m = newModel.(model)
+	if len(m.allTasks) != 5 {
+		t.Errorf("tasks of the latest fetch should be used, got %d tasks", len(m.allTasks))
+	}
+}
+
+func TestFetchErrorEndsTheRunningFetch(t *testing.T) {
+	m := CreateTestModel(nil)
+	m.fetchSeq = 2
+	m.fetching = true
+
+	newModel, _ := m.Update(fetchErrorMsg{err: errors.New("timed out"), Seq: 1})
+	if !newModel.(model).fetching {
+		t.Error("an error of an older fetch should not end the latest fetch")
+	}
+
+	newModel, _ = m.Update(fetchErrorMsg{err: errors.New("timed out"), Seq: 2})
+	if newModel.(model).fetching {
+		t.Error("an error of the latest fetch should end it")
+	}
+}
+
+func TestRefreshTimerStartsAfterStartup(t *testing.T) {
+	m := CreateTestModel(CreateTestTasks(3))
+
+	_, cmd := m.Update(initialTasksMsg{Tasks: CreateTestTasks(3)})
+	if cmd == nil {
+		t.Error("the refresh timer should start once startup is done")
+	}
+}
+
+func TestFetchErrorsBackOff(t *testing.T) {
+	start := time.Date(2025, 1, 5, 9, 0, 0, 0, time.Local)
+	SetTestClock(t, start)
+	m := CreateTestModel(nil)
+	m.fetchSeq = 1
+	m.fetching = true
+
+	newModel, _ := m.Update(fetchErrorMsg{err: errors.New("Things isn't running"), Seq: 1})
+	m = newModel.(model)
+	if m.fetchErr == nil || m.fetchFailures != 1 {
+		t.Fatalf("expected one failure to be recorded, got %d: %v", m.fetchFailures, m.fetchErr)
+	}
+	if !m.retryAt.Equal(start.Add(fetchBackoff(1))) {
+		t.Errorf("unexpected retry time %v", m.retryAt)
+	}
+
+	// Refreshing during the backoff doesn't fetch.
+	newModel, _ = m.Update(fetchMsg{})
+	if newModel.(model).fetchSeq != 1 {
+		t.Error("no fetch should start while backing off")
+	}
+
+	// Refreshing after the backoff fetches again.
+	SetTestClock(t, m.retryAt)
+	newModel, _ = m.Update(fetchMsg{})
+	m = newModel.(model)
+	if m.fetchSeq != 2 {
+		t.Error("a fetch should start once the backoff is over")
+	}
+
+	// A second failure doubles the backoff.
+	newModel, _ = m.Update(fetchErrorMsg{err: errors.New("Things isn't running"), Seq: 2})
+	m = newModel.(model)
+	if m.fetchFailures != 2 || !m.retryAt.Equal(now().Add(fetchBackoff(2))) {
+		t.Errorf("expected a second failure with a longer backoff, got %d until %v", m.fetchFailures, m.retryAt)
+	}
+
+	// A successful fetch clears the error.
+	newModel, _ = m.Update(tasksMsg{Tasks: CreateTestTasks(2), Seq: 2})
+	m = newModel.(model)
+	if m.fetchErr != nil || m.fetchFailures != 0 || !m.retryAt.IsZero() {
+		t.Error("a successful fetch should clear the error and backoff")
+	}
+	if !m.lastSync.Equal(now()) {
+		t.Errorf("expected last sync at %v, got %v", now(), m.lastSync)
+	}
+}
+
+func TestRetryKeyFetchesImmediately(t *testing.T) {
+	SetTestClock(t, time.Date(2025, 1, 5, 9, 0, 0, 0, time.Local))
+	m := CreateTestModel(CreateTestTasks(2))
+	m.fetchErr = errors.New("Things isn't running")
+	m.fetchFailures = 3
+	m.retryAt = now().Add(time.Minute)
+
+	newModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'r'}})
+	m = newModel.(model)
+	if m.fetchSeq != 1 || !m.fetching || !m.retryAt.IsZero() {
+		t.Errorf("expected a fetch to start right away, got seq=%d fetching=%v retryAt=%v", m.fetchSeq, m.fetching, m.retryAt)
+	}
+	if cmd == nil {
+		t.Error("expected a fetch command")
+	}
+}
+
+func TestSourceChangeStartsFetch(t *testing.T) {
+	m := CreateTestModel(CreateTestTasks(3))
+
+	newModel, _ := m.Update(sourceChangedMsg{})
+	m = newModel.(model)
+	if !m.fetching || m.fetchSeq != 1 {
+		t.Errorf("expected a change to start a fetch, got fetching=%v seq=%d", m.fetching, m.fetchSeq)
+	}
+}
+
+func TestSourceChangeDuringFetchFetchesAgain(t *testing.T) {
+	m := CreateTestModel(CreateTestTasks(3))
+	m.fetchSeq = 1
+	m.fetching = true
+
+	newModel, _ := m.Update(sourceChangedMsg{})
+	m = newModel.(model)
+	if m.fetchSeq != 1 {
+		t.Fatalf("a change should not start another fetch while one is running, got seq %d", m.fetchSeq)
+	}
+
+	newModel, _ = m.Update(tasksMsg{Tasks: CreateTestTasks(3), Seq: 1})
+	m = newModel.(model)
+	if !m.fetching || m.fetchSeq != 2 {
+		t.Errorf("expected the change to be fetched after the running fetch, got fetching=%v seq=%d", m.fetching, m.fetchSeq)
+	}
+	if m.changedWhileFetching {
+		t.Error("the change should be marked as fetched")
+	}
+}
+
+func TestUnchangedTasksAreNotSynced(t *testing.T) {
+	m := CreateTestModel(CreateTestTasks(3))
+	fetched := CreateTestTasks(3)
+	msg := tasksMsg{Tasks: fetched, Fingerprint: fingerprintTasks(fetched)}
+
+	newModel, _ := m.Update(msg)
+	m = newModel.(model)
+	if len(m.allTasks) != 3 || m.fingerprint != msg.Fingerprint {
+		t.Fatalf("expected the first fetch to be synced, got %d tasks", len(m.allTasks))
+	}
+
+	// Tasks that only exist locally would be removed by a sync.
+	m.allTasks = append(m.allTasks, task{ID: "local", Name: "Local", Status: StatusOpen})
+	m.fetchErr = errors.New("timed out")
+	newModel, _ = m.Update(msg)
+	m = newModel.(model)
+	if len(m.allTasks) != 4 {
+		t.Errorf("expected unchanged tasks to skip the sync, got %d tasks", len(m.allTasks))
+	}
+	if m.fetchErr != nil || m.lastSync.IsZero() {
+		t.Error("expected an unchanged fetch to still count as a sync")
+	}
+
+	fetched[0].Name = "Renamed"
+	newModel, _ = m.Update(tasksMsg{Tasks: fetched, Fingerprint: fingerprintTasks(fetched)})
+	m = newModel.(model)
+	if len(m.allTasks) != 3 || getTaskByID("a", m.allTasks).Name != "Renamed" {
+		t.Errorf("expected changed tasks to be synced, got %+v", m.allTasks)
+	}
+}
+
+func TestCachedTasksAreShownUntilFetched(t *testing.T) {
+	m := CreateTestModel([]task{})
+
+	newModel, _ := m.Update(cachedTasksMsg{Tasks: CreateTestTasks(3)})
+	m = newModel.(model)
+	if len(m.allTasks) != 3 || !m.stale {
+		t.Fatalf("expected the cached tasks to be shown as stale, got %d tasks, stale=%v", len(m.allTasks), m.stale)
+	}
+	AssertModelHasComparisonTasks(t, m)
+
+	newModel, _ = m.Update(tasksMsg{Tasks: CreateTestTasks(2)})
+	m = newModel.(model)
+	if len(m.allTasks) != 2 || m.stale {
+		t.Errorf("expected the fetched tasks to replace the cached ones, got %d tasks, stale=%v", len(m.allTasks), m.stale)
+	}
+}
+
+func TestCachedTasksAreIgnoredAfterFetch(t *testing.T) {
+	m := CreateTestModel([]task{})
+	newModel, _ := m.Update(tasksMsg{Tasks: CreateTestTasks(2)})
+	m = newModel.(model)
+
+	newModel, _ = m.Update(cachedTasksMsg{Tasks: CreateTestTasks(3)})
+	m = newModel.(model)
+	if len(m.allTasks) != 2 || m.stale {
+		t.Errorf("expected the cached tasks to be ignored, got %d tasks, stale=%v", len(m.allTasks), m.stale)
+	}
+}
+
+func TestTopTasksAreTaggedWhenTheyChange(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	tasks := CreateTestTasks(3)
+	m := CreateTestModel(tasks)
+	source := m.source.(*fakeSource)
+	source.capabilities.FocusCount = 1
+
+	newModel, cmd := m.Update(initialTasksMsg{Tasks: tasks})
+	m = newModel.(model)
+	runCmds(cmd)
+	// Nothing is prioritized yet, so tags from earlier runs are removed.
+	if len(source.focused) != 1 || len(source.focused[0]) != 0 {
+		t.Fatalf("expected the focus to be cleared at startup, got %+v", source.focused)
+	}
+
+	// Make a the top task.
+	m.taskA = getTaskByID("a", m.allTasks)
+	m.taskB = getTaskByID("b", m.allTasks)
+	newModel, cmd = m.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	m = newModel.(model)
+	runCmds(cmd)
+	if len(source.focused) != 1 {
+		t.Fatalf("the top is not known until a is above c too, got %+v", source.focused)
+	}
+	m.taskA = getTaskByID("a", m.allTasks)
+	m.taskB = getTaskByID("c", m.allTasks)
+	newModel, cmd = m.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	m = newModel.(model)
+	runCmds(cmd)
+	if len(source.focused) != 2 || len(source.focused[1]) != 1 || source.focused[1][0].ID != "a" {
+		t.Fatalf("expected a to be tagged, got %+v", source.focused)
+	}
+
+	// Completing a takes it out of the top.
+	fetched := CreateTestTasks(3)
+	fetched[0].Status = StatusCompleted
+	newModel, cmd = m.Update(tasksMsg{Tasks: fetched})
+	m = newModel.(model)
+	runCmds(cmd)
+	if len(source.focused) != 3 || len(source.focused[2]) != 0 {
+		t.Errorf("expected the completed task to be untagged, got %+v", source.focused)
+	}
+}
+
+func TestFailedFocusTagIsRetried(t *testing.T) {
+	m, source := createModelWithTopTask()
+	source.capabilities.FocusCount = 1
+	source.focusErr = errors.New("Things3 is not running")
+	m.loaded = true
+	m.fingerprint = "unchanged"
+
+	for _, msg := range runCmds(m.tagFocusIfChanged()) {
+		newModel, _ := m.Update(msg)
+		m = newModel.(model)
+	}
+	if m.focusTagged || m.tagging {
+		t.Error("expected the failed tag not to count as tagged")
+	}
+	if status := stripANSI(m.syncStatusView(80)); !strings.Contains(status, "Couldn't tag the top tasks") {
+		t.Errorf("expected the error in the status line, got %q", status)
+	}
+
+	// The next refresh tags them again, even though nothing changed.
+	source.focusErr = nil
+	newModel, cmd := m.Update(tasksMsg{Tasks: source.tasks, Fingerprint: "unchanged"})
+	m = newModel.(model)
+	for _, msg := range runCmds(cmd) {
+		newModel, _ := m.Update(msg)
+		m = newModel.(model)
+	}
+	if len(source.focused) != 2 || len(source.focused[1]) != 1 || source.focused[1][0].ID != "a" {
+		t.Fatalf("expected a to be tagged again, got %+v", source.focused)
+	}
+	if !m.focusTagged || !slices.Equal(m.focusIDs, []string{"a"}) {
+		t.Errorf("expected a to be recorded as tagged, got %v %v", m.focusTagged, m.focusIDs)
+	}
+	if m.tagFocusIfChanged() != nil {
+		t.Error("expected no tag while the top is unchanged")
+	}
+}
+
+// runCmds runs the command and the commands of any batch it returns, and
+// returns their messages.
+func runCmds(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	msg := cmd()
+	batch, ok := msg.(tea.BatchMsg)
+	if !ok {
+		return []tea.Msg{msg}
+	}
+	var msgs []tea.Msg
+	for _, cmd := range batch {
+		msgs = append(msgs, runCmds(cmd)...)
+	}
+	return msgs
+}
+
+// createModelWithTopTask returns a model where a is the top task, with b and
+// c below it.
+func createModelWithTopTask() (model, *fakeSource) {
+	tasks := CreateTestTasks(3)
+	tasks[1].ParentID = &tasks[0].ID
+	tasks[2].ParentID = &tasks[1].ID
+	m := CreateTestModel(tasks)
+	m.allTasks = tasks
+	source := m.source.(*fakeSource)
+	source.capabilities.WriteStatuses = []string{StatusCompleted, StatusCanceled}
+	return m, source
+}
+
+func TestCompleteKeyAsksBeforeClosingTopTask(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	m, source := createModelWithTopTask()
+
+	m = typeKeys(m, "c")
+	if m.prompt != promptClose || m.promptTaskID != "a" {
+		t.Fatalf("expected to be asked about a, got prompt %d for %q", m.prompt, m.promptTaskID)
+	}
+	if m.input.Prompt != `Complete "Task A"? ` {
+		t.Errorf("unexpected prompt %q", m.input.Prompt)
+	}
+	// Typed keys don't confirm anything.
+	m = typeKeys(m, "y")
+	if m.prompt != promptClose || m.input.Value() != "" {
+		t.Errorf("expected the prompt to ignore typing, got %q", m.input.Value())
+	}
+
+	newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = newModel.(model)
+	if m.prompt != promptNone || getTaskByID("a", m.allTasks).Status != StatusOpen || len(source.statuses) != 0 {
+		t.Error("expected esc to keep the task open")
+	}
+}
+
+func TestCompleteKeyClosesTopTaskRightAway(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	m, source := createModelWithTopTask()
+	m.fetchSeq = 4
+	m.fetching = true
+
+	m = typeKeys(m, "c")
+	newModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = newModel.(model)
+	if status := getTaskByID("a", m.allTasks).Status; status != StatusCompleted {
+		t.Fatalf("expected a to be completed right away, got %s", status)
+	}
+	if parent := getTaskByID("b", m.allTasks).ParentID; parent != nil {
+		t.Errorf("expected b to become a root task, got parent %s", *parent)
+	}
+	if m.fetchSeq != 5 || m.fetching || m.writes != 1 {
+		t.Errorf("expected the running fetch to be discarded, got seq=%d fetching=%v writes=%d", m.fetchSeq, m.fetching, m.writes)
+	}
+
+	var written statusWrittenMsg
+	for _, msg := range runCmds(cmd) {
+		if msg, ok := msg.(statusWrittenMsg); ok {
+			written = msg
+		}
+	}
+	if len(source.statuses) != 1 || source.statuses[0] != "a=completed" {
+		t.Fatalf("expected a to be completed in the source, got %v", source.statuses)
+	}
+
+	newModel, cmd = m.Update(written)
+	m = newModel.(model)
+	if m.writes != 0 || !m.fetching || m.writeErr != nil {
+		t.Errorf("expected a fetch after the write, got writes=%d fetching=%v err=%v", m.writes, m.fetching, m.writeErr)
+	}
+	if cmd == nil {
+		t.Error("expected the fetch command")
+	}
+}
+
+func TestFailedStatusWriteIsRolledBack(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	m, source := createModelWithTopTask()
+	source.statusErr = errors.New("Things3 got an error: Application isn't running")
+
+	m = typeKeys(m, "x")
+	newModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = newModel.(model)
+	if getTaskByID("a", m.allTasks).Status != StatusCanceled {
+		t.Fatal("expected a to be canceled right away")
+	}
+	var written statusWrittenMsg
+	for _, msg := range runCmds(cmd) {
+		if msg, ok := msg.(statusWrittenMsg); ok {
+			written = msg
+		}
+	}
+
+	newModel, _ = m.Update(written)
+	m = newModel.(model)
+	if status := getTaskByID("a", m.allTasks).Status; status != StatusOpen {
+		t.Errorf("expected a to be reopened, got %s", status)
+	}
+	if parent := getTaskByID("b", m.allTasks).ParentID; parent == nil || *parent != "a" {
+		t.Errorf("expected b to be below a again, got %v", parent)
+	}
+	if m.writeErr == nil {
+		t.Error("expected the error to be shown")
+	}
+
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	if newModel.(model).writeErr != nil {
+		t.Error("expected a key press to dismiss the error")
+	}
+}
+
+func TestCompleteKeyNeedsATask(t *testing.T) {
+	m := CreateTestModel(CreateTestTasks(3))
+	m.allTasks = CreateTestTasks(3)
+	m.source.(*fakeSource).capabilities.WriteStatuses = []string{StatusCompleted}
+
+	m = typeKeys(m, "c")
+	if m.prompt != promptNone {
+		t.Error("nothing should be completed without a top task or compared tasks")
+	}
+}
+
+func TestClosePromptCyclesThroughTopAndComparedTasks(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	m, source := createModelComparingBAndC()
+	source.capabilities.WriteStatuses = []string{StatusCompleted}
+
+	m = typeKeys(m, "c")
+	var asked []string
+	for range 3 {
+		asked = append(asked, m.promptTaskID)
+		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyTab})
+		m = newModel.(model)
+	}
+	if !slices.Equal(asked, []string{"a", "b", "c"}) {
+		t.Errorf("expected the prompt to go through a, b, and c, got %v", asked)
+	}
+	if m.promptTaskID != "a" {
+		t.Errorf("expected the prompt to wrap around to a, got %s", m.promptTaskID)
+	}
+
+	newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m = newModel.(model)
+	if m.input.Prompt != `Complete "Task B"? ` {
+		t.Errorf("unexpected prompt %q", m.input.Prompt)
+	}
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	runCmds(cmd)
+	if len(source.statuses) != 1 || source.statuses[0] != "b=completed" {
+		t.Errorf("expected b to be completed in the source, got %v", source.statuses)
+	}
+}
+
+func TestClosePromptStartsWithLeftTaskBeforeAnythingIsRanked(t *testing.T) {
+	tasks := CreateTestTasks(2)
+	m := CreateTestModel(tasks)
+	m.allTasks = tasks
+	m.taskA = &tasks[1]
+	m.taskB = &tasks[0]
+	m.source.(*fakeSource).capabilities.WriteStatuses = []string{StatusCanceled}
+
+	m = typeKeys(m, "x")
+	if m.prompt != promptClose || m.promptTaskID != "b" {
+		t.Errorf("expected to be asked about the left task b, got %q", m.promptTaskID)
+	}
+	if m.input.Prompt != `Cancel "Task B"? ` {
+		t.Errorf("unexpected prompt %q", m.input.Prompt)
+	}
+}
+
+func TestClosingOneOfTheLastComparedTasksEndsTheComparison(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	tasks := CreateTestTasks(2)
+	m := CreateTestModel(tasks)
+	m.allTasks = tasks
+	m.taskA = &task{ID: "a", Name: "Task A", Status: StatusOpen}
+	m.taskB = &task{ID: "b", Name: "Task B", Status: StatusOpen}
+	m.source.(*fakeSource).capabilities.WriteStatuses = []string{StatusCompleted}
+
+	m = typeKeys(m, "c")
+	newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = newModel.(model)
+	if m.taskA != nil || m.taskB != nil {
+		t.Fatalf("expected nothing left to compare, got %v and %v", m.taskA, m.taskB)
+	}
+
+	// Choosing the closed task anyway must not rank b below it.
+	m.chooseTask("a", "b")
+	if parent := getTaskByID("b", m.allTasks).ParentID; parent != nil {
+		t.Errorf("expected b to stay a root task, got parent %s", *parent)
+	}
+	if ranked, total := m.ranker.Progress(m.allTasks); ranked != 1 || total != 1 {
+		t.Errorf("expected b to be ranked, got %d of %d", ranked, total)
+	}
+}
+
+func TestCloseKeysNeedStatusesTheSourceSupports(t *testing.T) {
+	m, source := createModelWithTopTask()
+	// Like todo.txt, which has no canceled tasks.
+	source.capabilities.WriteStatuses = []string{StatusCompleted}
+
+	m = typeKeys(m, "x")
+	if m.prompt != promptNone {
+		t.Error("expected no prompt for a status the source can't set")
+	}
+	m = typeKeys(m, "c")
+	if m.prompt != promptClose {
+		t.Error("expected a prompt for a status the source can set")
+	}
+}
+
+func TestRefreshWaitsForWrites(t *testing.T) {
+	m := CreateTestModel(CreateTestTasks(3))
+	m.writes = 1
+
+	newModel, _ := m.Update(fetchMsg{})
+	m = newModel.(model)
+	if m.fetching || m.fetchSeq != 0 {
+		t.Errorf("expected no fetch while writing, got fetching=%v seq=%d", m.fetching, m.fetchSeq)
+	}
+}
+
+// typeKeys sends the text to the model one key at a time.
+func typeKeys(m model, text string) model {
+	for _, r := range text {
+		newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m = newModel.(model)
+	}
+	return m
+}
+
+func TestAddTaskPromptCreatesTask(t *testing.T) {
+	m := CreateTestModel(CreateTestTasks(2))
+	m.allTasks = CreateTestTasks(2)
+	source := m.source.(*fakeSource)
+	source.capabilities.CreateTasks = true
+
+	m = typeKeys(m, "a")
+	if m.prompt != promptAdd {
+		t.Fatal("expected the prompt to open")
+	}
+	// Keys like c and x are typed instead of closing tasks.
+	m = typeKeys(m, "Call dentist x")
+	if !strings.Contains(stripANSI(m.helpView()), "Add task: Call dentist x") {
+		t.Errorf("expected the prompt in the help view, got %q", stripANSI(m.helpView()))
+	}
+
+	newModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = newModel.(model)
+	if m.prompt != promptNone || m.writes != 1 {
+		t.Fatalf("expected the prompt to close and the task to be created, got adding=%v writes=%d", m.prompt != promptNone, m.writes)
+	}
+	created, ok := cmd().(taskCreatedMsg)
+	if !ok {
+		t.Fatal("expected a taskCreatedMsg")
+	}
+	if len(source.created) != 1 || source.created[0] != "Call dentist x" {
+		t.Errorf("expected the task to be created in the source, got %v", source.created)
+	}
+
+	newModel, _ = m.Update(created)
+	m = newModel.(model)
+	added := getTaskByID("new-Call dentist x", m.allTasks)
+	if added == nil || added.ParentID != nil {
+		t.Fatalf("expected the task to be added as a root, got %+v", m.allTasks)
+	}
+	if m.writes != 0 || !m.fetching {
+		t.Errorf("expected a fetch after the task was created, got writes=%d fetching=%v", m.writes, m.fetching)
+	}
+}
+
+func TestAddTaskPromptCanBeDismissed(t *testing.T) {
+	m := CreateTestModel(CreateTestTasks(2))
+	m.source.(*fakeSource).capabilities.CreateTasks = true
+
+	m = typeKeys(m, "aBuy milk")
+	newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = newModel.(model)
+	if m.prompt != promptNone || m.writes != 0 || len(m.source.(*fakeSource).created) != 0 {
+		t.Error("expected the prompt to close without creating a task")
+	}
+}
+
+func TestAddTaskPromptNeedsASourceThatCreatesTasks(t *testing.T) {
+	m := CreateTestModel(CreateTestTasks(2))
+
+	if m = typeKeys(m, "a"); m.prompt != promptNone {
+		t.Error("the prompt should not open when the source can't create tasks")
+	}
+}
+
+// createModelComparingBAndC returns a model where a is the top task, and b and
+// c are compared below it.
+func createModelComparingBAndC() (model, *fakeSource) {
+	tasks := CreateTestTasks(3)
+	tasks[1].ParentID = &tasks[0].ID
+	tasks[2].ParentID = &tasks[0].ID
+	m := CreateTestModel(tasks)
+	m.allTasks = tasks
+	m.taskA = &task{ID: "b", Name: "Task B", Status: StatusOpen, ParentID: &tasks[0].ID}
+	m.taskB = &task{ID: "c", Name: "Task C", Status: StatusOpen, ParentID: &tasks[0].ID}
+	m.history = []decision{{childID: "b", previousParentID: "", taskAID: "a", taskBID: "b"}}
+	source := m.source.(*fakeSource)
+	source.capabilities.RenameTasks = true
+	return m, source
+}
+
+func TestRenameKeepsComparison(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	m, source := createModelComparingBAndC()
+	source.renamedID = "b2"
+
+	m = typeKeys(m, "e")
+	if m.prompt != promptRename || m.input.Value() != "Task B" {
+		t.Fatalf("expected the prompt to rename Task B, got %v %q", m.prompt, m.input.Value())
+	}
+	newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	m = typeKeys(newModel.(model), "Bee")
+	newModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = newModel.(model)
+	if m.taskA.Name != "Task Bee" || getTaskByID("b", m.allTasks).Name != "Task Bee" {
+		t.Fatalf("expected the task to be renamed right away, got %q", m.taskA.Name)
+	}
+	if m.comparisonTasksNeedUpdated() {
+		t.Error("the comparison should not change because of the new name")
+	}
+
+	newModel, _ = m.Update(cmd())
+	m = newModel.(model)
+	if len(source.renames) != 1 || source.renames[0] != "b=Task Bee" {
+		t.Errorf("expected the task to be renamed in the source, got %v", source.renames)
+	}
+	if m.taskA.ID != "b2" || m.taskB.ID != "c" || getTaskByID("b2", m.allTasks) == nil {
+		t.Errorf("expected the comparison to keep the renamed task under its new ID, got %s and %s", m.taskA.ID, m.taskB.ID)
+	}
+	if m.history[0].childID != "b2" || m.history[0].taskBID != "b2" {
+		t.Errorf("expected the history to use the new ID, got %+v", m.history[0])
+	}
+	if m.comparisonTasksNeedUpdated() {
+		t.Error("the comparison should not change because of the new ID")
+	}
+}
+
+func TestFailedRenameIsRolledBack(t *testing.T) {
+	m, source := createModelComparingBAndC()
+	source.statusErr = errors.New("permission denied")
+
+	m = typeKeys(m, "E")
+	m = typeKeys(m, "!")
+	newModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = newModel.(model)
+	if m.taskB.Name != "Task C!" {
+		t.Fatalf("expected the task to be renamed right away, got %q", m.taskB.Name)
+	}
+
+	newModel, _ = m.Update(cmd())
+	m = newModel.(model)
+	if m.taskB.Name != "Task C" || getTaskByID("c", m.allTasks).Name != "Task C" || m.writeErr == nil {
+		t.Errorf("expected the name to be restored and the error shown, got %q", m.taskB.Name)
+	}
+}
+
+func TestSnoozeHidesTaskAndItsChildren(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	SetTestClock(t, time.Date(2025, 3, 10, 14, 30, 0, 0, time.UTC))
+	tasks := CreateTestTasks(4)
+	tasks[1].ParentID = &tasks[0].ID
+	tasks[2].ParentID = &tasks[1].ID
+	tasks[3].ParentID = &tasks[1].ID
+	m := CreateTestModel(tasks)
+	m.allTasks = tasks
+	m.taskA = &task{ID: "b", Name: "Task B", Status: StatusOpen, ParentID: &tasks[0].ID}
+	m.taskB = &task{ID: "a", Name: "Task A", Status: StatusOpen}
+
+	m = typeKeys(m, "z")
+	if m.prompt != promptSnooze || m.promptTaskID != "b" {
+		t.Fatalf("expected the prompt to snooze b, got %v %q", m.prompt, m.promptTaskID)
+	}
+	m = typeKeys(m, "2h")
+	newModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = newModel.(model)
+	if cmd == nil {
+		t.Fatal("expected commands to store the snooze")
+	}
+
+	if !getTaskByID("b", m.allTasks).Snoozed {
+		t.Fatal("expected b to be snoozed")
+	}
+	for _, id := range []string{"c", "d"} {
+		if parent := getTaskByID(id, m.allTasks).ParentID; parent == nil || *parent != "a" {
+			t.Errorf("expected %s to move up to a, got %v", id, parent)
+		}
+	}
+	if m.taskA.ID == "b" || m.taskB.ID == "b" {
+		t.Errorf("expected b to leave the comparison, got %s and %s", m.taskA.ID, m.taskB.ID)
+	}
+	for _, level := range assignLevels(m.allTasks) {
+		for _, task := range level {
+			if task.ID == "b" {
+				t.Error("expected b not to be in any level")
+			}
+		}
+	}
+
+	// b wakes up when its snooze expires.
+	SetTestClock(t, time.Date(2025, 3, 10, 16, 30, 0, 0, time.UTC))
+	newModel, _ = m.Update(snoozeExpiredMsg{})
+	m = newModel.(model)
+	if getTaskByID("b", m.allTasks).Snoozed || len(m.snoozed) != 0 {
+		t.Error("expected b to wake up")
+	}
+	if getTaskByID("b", m.allTasks).getLevel(m.allTasks) == -1 {
+		t.Error("expected b to be back in the levels")
+	}
+}
+
+func TestSnoozingOneOfTheLastComparedTasksEndsTheComparison(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	SetTestClock(t, time.Date(2025, 3, 10, 14, 30, 0, 0, time.UTC))
+	tasks := CreateTestTasks(2)
+	m := CreateTestModel(tasks)
+	m.allTasks = tasks
+	m.taskA = &task{ID: "a", Name: "Task A", Status: StatusOpen}
+	m.taskB = &task{ID: "b", Name: "Task B", Status: StatusOpen}
+
+	m = typeKeys(m, "Z2h")
+	newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = newModel.(model)
+	if !getTaskByID("b", m.allTasks).Snoozed {
+		t.Fatal("expected b to be snoozed")
+	}
+	if m.taskA != nil || m.taskB != nil {
+		t.Errorf("expected nothing left to compare, got %v and %v", m.taskA, m.taskB)
+	}
+}
+
+func TestInvalidSnoozeShowsError(t *testing.T) {
+	m, _ := createModelComparingBAndC()
+	m = typeKeys(m, "Zsoon")
+	newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = newModel.(model)
+	if m.writeErr == nil || len(m.snoozed) != 0 {
+		t.Errorf("expected an error and no snooze, got %v and %v", m.writeErr, m.snoozed)
+	}
+}
+
+func TestStoredSnoozesApplyToFetchedTasks(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	SetTestClock(t, time.Date(2025, 3, 10, 14, 30, 0, 0, time.UTC))
+	m := CreateTestModel(CreateTestTasks(3))
+	m.allTasks = CreateTestTasks(3)
+
+	newModel, _ := m.Update(snoozesMsg{Snoozed: map[string]time.Time{
+		"a": time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC),
+		"b": time.Date(2025, 3, 9, 0, 0, 0, 0, time.UTC),
+	}})
+	m = newModel.(model)
+	if !getTaskByID("a", m.allTasks).Snoozed {
+		t.Error("expected a to be snoozed")
+	}
+	if getTaskByID("b", m.allTasks).Snoozed {
+		t.Error("expected the expired snooze of b to be dropped")
+	}
+
+	m.fetching = true
+	m.fetchSeq = 1
+	newModel, _ = m.Update(tasksMsg{Tasks: CreateTestTasks(3), Seq: 1, Fingerprint: "new"})
+	m = newModel.(model)
+	if !getTaskByID("a", m.allTasks).Snoozed {
+		t.Error("expected a to stay snoozed after a fetch")
+	}
+}
@@ -3,6 +3,8 @@ package main
 import (
 	"fmt"
 	"strings"
+	"time"
+	"unicode"
 
 	"github.com/charmbracelet/lipgloss"
 )
@@ -31,8 +33,48 @@ func sectionHeader(s string, width int) string {
 	return header + "\n"
 }
 
+// taskDetails returns the metadata and notes of a task, one item per line, or
+// an empty string if the task has none.
+func taskDetails(t task) string {
+	var lines []string
+	var location []string
+	for _, s := range []string{t.Area, t.Project} {
+		if s != "" {
+			location = append(location, s)
+		}
+	}
+	if len(location) > 0 {
+		lines = append(lines, strings.Join(location, " › "))
+	}
+	if len(t.Tags) > 0 {
+		var tags []string
+		for _, tag := range t.Tags {
+			tags = append(tags, "#"+tag)
+		}
+		lines = append(lines, strings.Join(tags, " "))
+	}
+	var dates []string
+	if t.Scheduled != nil {
+		dates = append(dates, "Scheduled "+t.Scheduled.Local().Format("Jan 2"))
+	}
+	if t.Deadline != nil {
+		dates = append(dates, "Due "+t.Deadline.Local().Format("Jan 2"))
+	}
+	if len(dates) > 0 {
+		lines = append(lines, strings.Join(dates, " · "))
+	}
+	if notes := strings.TrimSpace(t.Notes); notes != "" {
+		lines = append(lines, notes)
+	}
+	return strings.Join(lines, "\n")
+}
+
 func (m model) helpView() string {
 	helpContent := m.help.View(m.keys)
+	if m.prompt != promptNone {
+		// The prompt replaces the help.
+		helpContent = m.input.View()
+	}
 
 	// Create simple logo without centering
 	space := lipgloss.NewStyle().
@@ -48,14 +90,86 @@ func (m model) helpView() string {
 	// Calculate remaining width and align logo right
 	remainingWidth := max(0, m.width-lipgloss.Width(helpContent)-lipgloss.Width(logoContent))
 
+	// Show the sync status next to the logo, keeping two spaces on each side.
+	status := m.syncStatusView(remainingWidth - 4)
+	if status != "" {
+		status += "  "
+		remainingWidth -= lipgloss.Width(status)
+	}
+
 	return smallHorizontalRule() + lipgloss.JoinHorizontal(
 		lipgloss.Bottom,
 		helpContent,
 		strings.Repeat(" ", remainingWidth),
+		status,
 		logoContent,
 	)
 }
 
+// syncStatusView returns when the tasks were last synced with the source, or
+// why syncing fails and when it will be retried, in at most maxWidth cells.
+// The error of a failed write takes precedence. It returns an empty string if
+// there's nothing to show or no room for it.
+func (m model) syncStatusView(maxWidth int) string {
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+
+	var lastSync string
+	switch {
+	case m.stale:
+		lastSync = "Showing cached tasks"
+	case !m.lastSync.IsZero():
+		lastSync = "Synced " + m.lastSync.Format(time.TimeOnly)
+	}
+	if m.writeErr != nil {
+		message := []rune(strings.Join(strings.Fields(m.writeErr.Error()), " "))
+		if maxWidth < 12 {
+			return ""
+		}
+		if len(message) > maxWidth {
+			message = append(message[:maxWidth-1], '…')
+		}
+		message[0] = unicode.ToUpper(message[0])
+		return errorStyle.Render(string(message))
+	}
+	if m.fetchErr == nil {
+		if lastSync == "" || len(lastSync) > maxWidth {
+			return ""
+		}
+		if m.stale {
+			return lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Render(lastSync)
+		}
+		return dimStyle.Render(lastSync)
+	}
+
+	var details []string
+	if wait := m.retryAt.Sub(now()).Round(time.Second); wait > 0 {
+		details = append(details, fmt.Sprintf("retrying in %s", wait))
+	}
+	if lastSync != "" {
+		details = append(details, strings.ToLower(lastSync[:1])+lastSync[1:])
+	}
+	suffix := ""
+	if len(details) > 0 {
+		suffix = " · " + strings.Join(details, " · ")
+	}
+
+	message := []rune("Sync failed: " + strings.Join(strings.Fields(m.fetchErr.Error()), " "))
+	available := maxWidth - len([]rune(suffix))
+	if available < 12 {
+		// There's no room for the details, so only show that syncing failed.
+		suffix = ""
+		available = maxWidth
+	}
+	if available < 12 {
+		return ""
+	}
+	if len(message) > available {
+		message = append(message[:available-1], '…')
+	}
+	return errorStyle.Render(string(message)) + dimStyle.Render(suffix)
+}
+
 // NOTE: We pass this string to the viewport with viewport.SetContent(), which
 // is why it's a separate function from View().
 func (m model) viewContent() string {
@@ -67,7 +181,8 @@ func (m model) viewContent() string {
 	canceledMark := "✕"
 
 	completedTasks := []task{}
-	prioritizedTasks := []task{}
+	snoozedTasks := []task{}
+	prioritizedTasks, pendingTasks := m.ranker.Ordered(m.allTasks)
 
 	// Group the tasks for use later.
 	for _, task := range m.allTasks {
@@ -75,9 +190,8 @@ func (m model) viewContent() string {
 			completedTasks = append(completedTasks, task)
 			continue
 		}
-		if task.isFullyPrioritized(m.allTasks) {
-			prioritizedTasks = append(prioritizedTasks, task)
-			continue
+		if task.Snoozed {
+			snoozedTasks = append(snoozedTasks, task)
 		}
 	}
 
@@ -106,6 +220,16 @@ func (m model) viewContent() string {
 		s += "\n"
 	}
 
+	if len(snoozedTasks) > 0 {
+		s += sectionHeader("Snoozed", m.width) + "\n"
+		snoozedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+		for _, task := range snoozedTasks {
+			until := m.snoozed[task.ID].Format("Mon Jan 2 15:04")
+			s += snoozedStyle.Render(openMark+" "+task.Name+" until "+until) + "\n"
+		}
+		s += "\n"
+	}
+
 	prioritizedStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("4"))
 
@@ -113,9 +237,8 @@ func (m model) viewContent() string {
 		s += sectionHeader("Prioritized", m.width) + "\n"
 	}
 
-	prioritizedLevels := assignLevels(prioritizedTasks)
-	maxLevel := len(prioritizedLevels)
-	for i, tasks := range prioritizedLevels {
+	maxLevel := len(prioritizedTasks)
+	for i, task := range prioritizedTasks {
 		level := fmt.Sprintf("%d", i+1)
 		if maxLevel >= 10 && i+1 < 10 {
 			level = " " + level
@@ -125,17 +248,23 @@ func (m model) viewContent() string {
 			Background(lipgloss.Color("4")).
 			Foreground(lipgloss.Color("0")).
 			Render(level)
-		for _, task := range tasks {
-			levelStr := level
-			s += prioritizedStyle.Render(levelStr + " " + openMark + " " + task.Name)
-			s += "\n"
-		}
+		s += prioritizedStyle.Render(level + " " + openMark + " " + task.Name)
+		s += "\n"
 	}
 
 	// Task comparison.
 	if m.taskA != nil && m.taskB != nil {
 		taskA := m.taskA.Name
 		taskB := m.taskB.Name
+		if m.showDetails {
+			detailsStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+			if details := taskDetails(*m.taskA); details != "" {
+				taskA += "\n\n" + detailsStyle.Render(details)
+			}
+			if details := taskDetails(*m.taskB); details != "" {
+				taskB += "\n\n" + detailsStyle.Render(details)
+			}
+		}
 
 		if len(prioritizedTasks) > 0 {
 			s += "\n"
@@ -200,27 +329,24 @@ func (m model) viewContent() string {
 		s += choices + "\n\n"
 	}
 
-	levels := assignLevels(m.allTasks)
-	highestLevel := getHighestLevelWithMultipleTasks(levels)
 	lowerLevelStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("8"))
 
-	for ilvl, tasks := range levels {
-		level := fmt.Sprintf("%d", ilvl+1)
+	// The pending tasks are numbered after the prioritized ones, and the first
+	// group is the one being compared.
+	for ilvl, tasks := range pendingTasks {
+		level := fmt.Sprintf("%d", len(prioritizedTasks)+ilvl+1)
 		for itask, task := range tasks {
-			if task.isFullyPrioritized(m.allTasks) {
-				continue
-			}
 			levelStr := level + "?"
 			mark := openMark
 			taskStr := levelStr + " " + mark + " " + task.Name
-			if ilvl != highestLevel {
+			if ilvl != 0 {
 				s += lowerLevelStyle.Render(taskStr)
 			} else {
 				s += taskStr
 			}
 			// Don't add newline after the very last task of the very last level
-			if ilvl != len(levels)-1 || itask != len(tasks)-1 {
+			if ilvl != len(pendingTasks)-1 || itask != len(tasks)-1 {
 				s += "\n"
 			}
 		}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"regexp"
 	"strings"
 	"testing"
@@ -25,7 +26,7 @@ func setupModelForViewTest() model {
 
 func TestViewIsNotEmpty(t *testing.T) {
 	m := setupModelForViewTest()
-	tasks := getTasksFromThings().(tasksMsg).Tasks
+	tasks := CreateTestTasks(5)
 	m.allTasks = tasks
 	m.viewport.SetContent(m.viewContent())
 	v := m.View()
@@ -36,7 +37,7 @@ func TestViewIsNotEmpty(t *testing.T) {
 
 func TestViewDisplaysTasksInLevelOrder(t *testing.T) {
 	m := setupModelForViewTest()
-	tasks := getTasksFromThings().(tasksMsg).Tasks
+	tasks := CreateTestTasks(5)
 	if len(tasks) < 2 {
 		t.Skip("Not enough tasks to test level order")
 	}
@@ -52,7 +53,7 @@ func TestViewDisplaysTasksInLevelOrder(t *testing.T) {
 
 func TestViewDisplaysComparisonPromptWhenTasksSet(t *testing.T) {
 	m := setupModelForViewTest()
-	tasks := getTasksFromThings().(tasksMsg).Tasks
+	tasks := CreateTestTasks(5)
 	if len(tasks) < 2 {
 		t.Skip("Not enough tasks to test comparison")
 	}
@@ -68,7 +69,7 @@ func TestViewDisplaysComparisonPromptWhenTasksSet(t *testing.T) {
 
 func TestViewHidesComparisonPromptWhenNoComparisonTasks(t *testing.T) {
 	m := setupModelForViewTest()
-	tasks := getTasksFromThings().(tasksMsg).Tasks
+	tasks := CreateTestTasks(5)
 	m.allTasks = tasks
 	m.taskA = nil
 	m.taskB = nil
@@ -81,7 +82,7 @@ func TestViewHidesComparisonPromptWhenNoComparisonTasks(t *testing.T) {
 
 func TestViewStylesFullyPrioritizedTasksDifferently(t *testing.T) {
 	m := setupModelForViewTest()
-	tasks := getTasksFromThings().(tasksMsg).Tasks
+	tasks := CreateTestTasks(5)
 	if len(tasks) == 0 {
 		t.Skip("No tasks to test")
 	}
@@ -642,3 +643,110 @@ func removeANSIEscapes(s string) string {
 	}
 	return result
 }
+
+func TestTaskDetails(t *testing.T) {
+	deadline := time.Date(2025, 1, 10, 12, 0, 0, 0, time.Local)
+	details := taskDetails(task{
+		Name:     "Write report",
+		Area:     "Work",
+		Project:  "Launch",
+		Tags:     []string{"Writing"},
+		Deadline: &deadline,
+		Notes:    "Include the numbers\n",
+	})
+	expected := "Work › Launch\n#Writing\nDue Jan 10\nInclude the numbers"
+	if details != expected {
+		t.Errorf("expected %q, got %q", expected, details)
+	}
+
+	if details := taskDetails(task{Name: "Plain"}); details != "" {
+		t.Errorf("expected no details, got %q", details)
+	}
+}
+
+func TestViewShowsDetailsOfComparedTasksWhenToggled(t *testing.T) {
+	m := setupModelForViewTest()
+	tasks := CreateTestTasks(2)
+	tasks[0].Notes = "Some notes"
+	m.allTasks = tasks
+	m.taskA = &tasks[0]
+	m.taskB = &tasks[1]
+
+	if strings.Contains(stripANSI(m.viewContent()), "Some notes") {
+		t.Error("details should be hidden by default")
+	}
+	m.showDetails = true
+	if !strings.Contains(stripANSI(m.viewContent()), "Some notes") {
+		t.Error("details should be shown when toggled")
+	}
+}
+
+func TestHelpViewShowsLastSync(t *testing.T) {
+	m := setupModelForViewTest()
+	m.lastSync = time.Date(2025, 1, 5, 9, 30, 15, 0, time.Local)
+
+	if !strings.Contains(stripANSI(m.helpView()), "Synced 09:30:15") {
+		t.Errorf("expected the last sync time, got %q", stripANSI(m.helpView()))
+	}
+}
+
+func TestHelpViewShowsFetchErrorAndRetry(t *testing.T) {
+	SetTestClock(t, time.Date(2025, 1, 5, 9, 31, 0, 0, time.Local))
+	m := setupModelForViewTest()
+	m.width = 160
+	m.lastSync = time.Date(2025, 1, 5, 9, 30, 15, 0, time.Local)
+	m.fetchErr = errors.New("osascript timed out after 10s")
+	m.retryAt = now().Add(12 * time.Second)
+
+	help := stripANSI(m.helpView())
+	for _, expected := range []string{"Sync failed: osascript timed out after 10s", "retrying in 12s", "synced 09:30:15"} {
+		if !strings.Contains(help, expected) {
+			t.Errorf("expected %q in %q", expected, help)
+		}
+	}
+}
+
+func TestSyncStatusViewTruncatesLongErrors(t *testing.T) {
+	m := setupModelForViewTest()
+	m.fetchErr = errors.New(strings.Repeat("very long error ", 20))
+
+	status := stripANSI(m.syncStatusView(30))
+	if len([]rune(status)) > 30 {
+		t.Errorf("status should fit in 30 cells, got %q", status)
+	}
+	if !strings.HasSuffix(status, "…") {
+		t.Errorf("expected a truncated status, got %q", status)
+	}
+	if m.syncStatusView(5) != "" {
+		t.Error("status should be hidden when there's no room")
+	}
+}
+
+func TestSyncStatusViewShowsCachedTasks(t *testing.T) {
+	m := setupModelForViewTest()
+	m.stale = true
+
+	if status := stripANSI(m.syncStatusView(40)); status != "Showing cached tasks" {
+		t.Errorf("expected the tasks to be marked as cached, got %q", status)
+	}
+
+	m.fetchErr = errors.New("osascript timed out after 10s")
+	if status := stripANSI(m.syncStatusView(80)); !strings.Contains(status, "showing cached tasks") {
+		t.Errorf("expected the failed sync to mention the cached tasks, got %q", status)
+	}
+}
+
+func TestViewShowsSnoozedTasks(t *testing.T) {
+	m := setupModelForViewTest()
+	m.allTasks = CreateTestTasks(3)
+	m.allTasks[1].Snoozed = true
+	m.snoozed = map[string]time.Time{"b": time.Date(2025, 3, 11, 9, 0, 0, 0, time.UTC)}
+
+	content := stripANSI(m.viewContent())
+	if !strings.Contains(content, "Snoozed") || !strings.Contains(content, "Task B until Tue Mar 11 09:00") {
+		t.Errorf("expected the snoozed task with its wake-up time, got %q", content)
+	}
+	if strings.Contains(content, "? ○ Task B") {
+		t.Errorf("expected the snoozed task not to be listed as unprioritized, got %q", content)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is how long the watcher waits for more changes before it
+// reports them, since editors often write a file in several steps.
+const watchDebounce = 150 * time.Millisecond
+
+// sourceWatcher reports changes to the files of a source, so they can be
+// fetched as soon as they change instead of on every refresh interval.
+type sourceWatcher struct {
+	watcher *fsnotify.Watcher
+	// changes receives a value after a burst of changes has settled.
+	changes chan struct{}
+}
+
+// newSourceWatcher watches the given files and directories. Files are watched
+// through their directory, so they're still watched after an editor replaces
+// them with a new file.
+func newSourceWatcher(paths []string) (*sourceWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	// files holds the watched files, and dirs the directories that are watched
+	// as a whole.
+	files := make(map[string]bool)
+	dirs := make(map[string]bool)
+	for _, path := range paths {
+		path = filepath.Clean(path)
+		dir := filepath.Dir(path)
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			dir = path
+			dirs[path] = true
+		} else {
+			files[path] = true
+		}
+		if err := watcher.Add(dir); err != nil {
+			_ = watcher.Close()
+			return nil, err
+		}
+	}
+
+	w := &sourceWatcher{
+		watcher: watcher,
+		changes: make(chan struct{}, 1),
+	}
+	go w.run(func(name string) bool {
+		name = filepath.Clean(name)
+		return files[name] || dirs[filepath.Dir(name)]
+	})
+	return w, nil
+}
+
+// run forwards the events of the watched paths to changes, once no more events
+// arrived for watchDebounce.
+func (w *sourceWatcher) run(isWatched func(name string) bool) {
+	debounce := time.NewTimer(watchDebounce)
+	debounce.Stop()
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				debounce.Stop()
+				close(w.changes)
+				return
+			}
+			if event.Has(fsnotify.Chmod) || !isWatched(event.Name) {
+				continue
+			}
+			debounce.Reset(watchDebounce)
+		case err, ok := <-w.watcher.Errors:
+			if ok {
+				Logger.Error(err)
+			}
+		case <-debounce.C:
+			select {
+			case w.changes <- struct{}{}:
+			default:
+				// A change is already waiting to be picked up.
+			}
+		}
+	}
+}
+
+// waitForChange returns a command that waits for the next change.
+func (w *sourceWatcher) waitForChange() tea.Cmd {
+	return func() tea.Msg {
+		if _, ok := <-w.changes; !ok {
+			// The watcher was closed.
+			return nil
+		}
+		return sourceChangedMsg{}
+	}
+}
+
+// Close stops watching.
+func (w *sourceWatcher) Close() error {
+	return w.watcher.Close()
+}
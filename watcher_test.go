@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// waitForWatcher returns true if the watcher reports a change within timeout.
+func waitForWatcher(w *sourceWatcher, timeout time.Duration) bool {
+	select {
+	case <-w.changes:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+func TestSourceWatcherReportsChangedFile(t *testing.T) {
+	path := writeTestFile(t, "todo.txt", "Buy milk\n")
+	w, err := newSourceWatcher([]string{path})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer w.Close()
+
+	// Editors often write files in several steps, which should be reported as
+	// one change.
+	for _, content := range []string{"Buy milk\n", "Buy milk\nCall mom\n"} {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !waitForWatcher(w, 2*time.Second) {
+		t.Fatal("expected a change to be reported")
+	}
+	if waitForWatcher(w, 3*watchDebounce) {
+		t.Error("expected the writes to be reported as one change")
+	}
+}
+
+func TestSourceWatcherIgnoresOtherFiles(t *testing.T) {
+	path := writeTestFile(t, "todo.txt", "Buy milk\n")
+	w, err := newSourceWatcher([]string{path})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer w.Close()
+
+	other := filepath.Join(filepath.Dir(path), "done.txt")
+	if err := os.WriteFile(other, []byte("x Call mom\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if waitForWatcher(w, 3*watchDebounce) {
+		t.Error("expected changes to other files to be ignored")
+	}
+}
+
+func TestSourceWatcherReportsFilesInWatchedDirectory(t *testing.T) {
+	dir := t.TempDir()
+	w, err := newSourceWatcher([]string{dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer w.Close()
+
+	if err := os.WriteFile(filepath.Join(dir, "work.ics"), []byte("BEGIN:VCALENDAR\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if !waitForWatcher(w, 2*time.Second) {
+		t.Error("expected a new file in the directory to be reported")
+	}
+}
+
+func TestSourceWatcherFailsForMissingDirectory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "todo.txt")
+	if _, err := newSourceWatcher([]string{path}); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}